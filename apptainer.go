@@ -0,0 +1,180 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cyverse-de/model/v8/submitfile"
+)
+
+const (
+	// ApptainerExec runs a command inside the container.
+	ApptainerExec = "exec"
+
+	// ApptainerRun runs the container's runscript.
+	ApptainerRun = "run"
+)
+
+// ApptainerOptions contains the settings used when rendering the apptainer
+// command-line for a step.
+type ApptainerOptions struct {
+	// The apptainer (or singularity) executable. Defaults to "apptainer".
+	Executable string
+
+	// Either ApptainerExec or ApptainerRun. Defaults to ApptainerExec.
+	Command string
+
+	// The directory on the execution node that gets bound to the container's
+	// working directory. The working directory isn't bound if this is empty.
+	HostWorkingDir string
+
+	Contain  bool // Adds --contain, which keeps the host's home and /tmp out of the container.
+	CleanEnv bool // Adds --cleanenv, which keeps the host's environment out of the container.
+	GPU      bool // Adds --nv, which makes the host's NVIDIA GPUs available.
+}
+
+// ApptainerImage returns the image that apptainer should run for the step. The
+// OSG image path is used if it's set; otherwise the docker image is pulled with
+// a docker:// URI.
+func (s *Step) ApptainerImage() (string, error) {
+	img := s.Component.Container.Image
+	if img.OSGImagePath != "" {
+		return img.OSGImagePath, nil
+	}
+	if img.Name == "" {
+		return "", errors.New("the step has neither an OSG image path nor an image name")
+	}
+	ref, err := img.Reference()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("docker://%s", ref.String()), nil
+}
+
+// ApptainerBindArguments returns the --bind and --scratch options for the
+// step's volumes and data containers. Apptainer can't run data containers, so
+// their host paths are bound directly. Volumes without a host path become
+// scratch directories. Bind options other than ro are dropped, since apptainer
+// doesn't support them.
+func (s *Step) ApptainerBindArguments() []string {
+	var args []string
+	c := &s.Component.Container
+	for _, v := range c.Volumes {
+		if v.HostPath == "" {
+			args = append(args, "--scratch", v.ContainerPath)
+			continue
+		}
+		args = append(args, "--bind", apptainerBind(v.HostPath, v.ContainerPath, v.ReadOnly))
+	}
+	for _, vf := range c.VolumesFrom {
+		if vf.HostPath == "" || vf.ContainerPath == "" {
+			continue
+		}
+		args = append(args, "--bind", apptainerBind(vf.HostPath, vf.ContainerPath, vf.ReadOnly))
+	}
+	return args
+}
+
+func apptainerBind(hostPath, containerPath string, readOnly bool) string {
+	if readOnly {
+		return fmt.Sprintf("%s:%s:ro", hostPath, containerPath)
+	}
+	return fmt.Sprintf("%s:%s", hostPath, containerPath)
+}
+
+// ApptainerEnvArguments returns the --env options that set the step's
+// environment variables, sorted by name so that the output is stable.
+func (s *Step) ApptainerEnvArguments() []string {
+	env := s.EnvironmentWithParams()
+	var names []string
+	for k := range env {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	var args []string
+	for _, k := range names {
+		args = append(args, "--env", fmt.Sprintf("%s=%s", k, env[k]))
+	}
+	return args
+}
+
+// ApptainerArguments returns the argv for running the step with apptainer,
+// starting with the executable. With ApptainerExec the container's entrypoint
+// is used as the command when it's set, followed by Step.Arguments().
+func (s *Step) ApptainerArguments(opts ApptainerOptions) ([]string, error) {
+	executable := opts.Executable
+	if executable == "" {
+		executable = "apptainer"
+	}
+	command := opts.Command
+	if command == "" {
+		command = ApptainerExec
+	}
+	if command != ApptainerExec && command != ApptainerRun {
+		return nil, fmt.Errorf("unsupported apptainer command %q", command)
+	}
+
+	image, err := s.ApptainerImage()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{executable, command}
+	if opts.Contain {
+		args = append(args, "--contain")
+	}
+	if opts.CleanEnv {
+		args = append(args, "--cleanenv")
+	}
+	if opts.GPU {
+		args = append(args, "--nv")
+	}
+
+	workingDir := s.Component.Container.WorkingDirectory()
+	if opts.HostWorkingDir != "" {
+		args = append(args, "--bind", apptainerBind(opts.HostWorkingDir, workingDir, false))
+	}
+	args = append(args, s.ApptainerBindArguments()...)
+	args = append(args, "--pwd", workingDir)
+	args = append(args, s.ApptainerEnvArguments()...)
+	args = append(args, image)
+
+	if command == ApptainerExec {
+		entrypoint := strings.TrimSpace(s.Component.Container.EntryPoint)
+		if entrypoint != "" {
+			args = append(args, entrypoint)
+		}
+	}
+	args = append(args, s.Arguments()...)
+	return args, nil
+}
+
+// HTCondorSingularityAttributes returns the lines that need to be added to an
+// HTCondor submit file so that OSG runs the job inside the steps' image. Every
+// step must use the same OSG image, since HTCondor only supports one image per
+// job. CVMFS is bound into the container when the image is stored there.
+func (job *Job) HTCondorSingularityAttributes() ([]string, error) {
+	var imagePath string
+	for i, step := range job.Steps {
+		p := step.Component.Container.Image.OSGImagePath
+		if p == "" {
+			return nil, fmt.Errorf("step %d does not have an OSG image path", i)
+		}
+		if imagePath != "" && p != imagePath {
+			return nil, fmt.Errorf("step %d uses OSG image %s instead of %s", i, p, imagePath)
+		}
+		imagePath = p
+	}
+	if imagePath == "" {
+		return nil, errors.New("the job has no steps")
+	}
+
+	attrs := []string{fmt.Sprintf("+SingularityImage = %s", submitfile.FormatString(imagePath))}
+	if strings.HasPrefix(path.Clean(imagePath), "/cvmfs/") {
+		attrs = append(attrs, "+SingularityBindCVMFS = True")
+	}
+	return attrs, nil
+}
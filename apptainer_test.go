@@ -0,0 +1,112 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApptainerImage(t *testing.T) {
+	s := inittestsFile(t, "test/test_submission_osg.json")
+	actual, err := s.Steps[0].ApptainerImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != "/path/to/image" {
+		t.Errorf("ApptainerImage() returned '%s' instead of '/path/to/image'", actual)
+	}
+
+	s.Steps[0].Component.Container.Image.OSGImagePath = ""
+	actual, err = s.Steps[0].ApptainerImage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "docker://gims.iplantcollaborative.org:5000/backwards-compat:latest"
+	if actual != expected {
+		t.Errorf("ApptainerImage() returned '%s' instead of '%s'", actual, expected)
+	}
+}
+
+func TestApptainerArguments(t *testing.T) {
+	s := inittestsFile(t, "test/test_submission_osg.json")
+	actual, err := s.Steps[0].ApptainerArguments(ApptainerOptions{
+		HostWorkingDir: "/scratch/job",
+		Contain:        true,
+		CleanEnv:       true,
+		GPU:            true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"apptainer", "exec", "--contain", "--cleanenv", "--nv",
+		"--bind", "/scratch/job:/work",
+		"--bind", "/host/path1:/container/path1",
+		"--scratch", "/container/path2",
+		"--bind", "/host/path1:/container/path1:ro",
+		"--bind", "/host/path2:/container/path2:ro",
+		"--pwd", "/work",
+		"--env", "foo=bar",
+		"--env", "food=banana",
+		"/path/to/image",
+		"/bin/true",
+		"/usr/local3/bin/wc_tool-1.00/wc_wrapper.sh",
+		"param1", "Acer-tree.txt",
+		"param0", "wc_out.txt",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ApptainerArguments() returned:\n\t%#v\ninstead of:\n\t%#v", actual, expected)
+	}
+
+	s.Steps[0].Component.Container.Volumes = nil
+	s.Steps[0].Component.Container.VolumesFrom = nil
+	s.Steps[0].Environment = nil
+	actual, err = s.Steps[0].ApptainerArguments(ApptainerOptions{Executable: "singularity", Command: ApptainerRun})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{
+		"singularity", "run",
+		"--pwd", "/work",
+		"/path/to/image",
+		"/usr/local3/bin/wc_tool-1.00/wc_wrapper.sh",
+		"param1", "Acer-tree.txt",
+		"param0", "wc_out.txt",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ApptainerArguments() returned:\n\t%#v\ninstead of:\n\t%#v", actual, expected)
+	}
+
+	if _, err = s.Steps[0].ApptainerArguments(ApptainerOptions{Command: "shell"}); err == nil {
+		t.Error("ApptainerArguments() did not return an error for an unsupported command")
+	}
+}
+
+func TestHTCondorSingularityAttributes(t *testing.T) {
+	s := inittestsFile(t, "test/test_submission_osg.json")
+	actual, err := s.HTCondorSingularityAttributes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{`+SingularityImage = "/path/to/image"`}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("HTCondorSingularityAttributes() returned %#v instead of %#v", actual, expected)
+	}
+
+	s.Steps[0].Component.Container.Image.OSGImagePath = "/cvmfs/singularity.opensciencegrid.org/wc:latest"
+	actual, err = s.HTCondorSingularityAttributes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{
+		`+SingularityImage = "/cvmfs/singularity.opensciencegrid.org/wc:latest"`,
+		"+SingularityBindCVMFS = True",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("HTCondorSingularityAttributes() returned %#v instead of %#v", actual, expected)
+	}
+
+	s = inittests(t)
+	if _, err = s.HTCondorSingularityAttributes(); err == nil {
+		t.Error("HTCondorSingularityAttributes() did not return an error for a job without an OSG image")
+	}
+}
@@ -0,0 +1,121 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// BackwardsCompatConfigKey is the configuration setting containing the list of
+// backwards compatible image patterns.
+const BackwardsCompatConfigKey = "condor.backwards_compat_patterns"
+
+// DefaultBackwardsCompatPatterns contains the prefixes of the images that were
+// put together to maintain compatibility with non-dockerized versions of the DE.
+var DefaultBackwardsCompatPatterns = []string{
+	"discoenv/backwards-compat",
+	"gims.iplantcollaborative.org:5000/backwards-compat",
+	"docker.cyverse.org/backwards-compat",
+	"harbor.cyverse.org/legacy/backwards-compat",
+}
+
+// backwardsCompatRule is a single compiled backwards compatible image pattern.
+type backwardsCompatRule struct {
+	pattern string
+	matches func(string) bool
+}
+
+// BackwardsCompatClassifier determines whether an image name refers to a
+// backwards compatible image. Patterns are plain prefixes by default, which can
+// also be written with an explicit "prefix:" prefix, e.g. for prefixes that
+// start with "glob:". A "glob:" prefix makes a pattern a path.Match glob and a
+// "regex:" prefix makes it a regular expression. Globs and regular expressions
+// must match the entire image name.
+type BackwardsCompatClassifier struct {
+	rules []backwardsCompatRule
+}
+
+// NewBackwardsCompatClassifier compiles the patterns into a classifier.
+func NewBackwardsCompatClassifier(patterns []string) (*BackwardsCompatClassifier, error) {
+	c := &BackwardsCompatClassifier{}
+	for _, p := range patterns {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		rule := backwardsCompatRule{pattern: p}
+		switch {
+		case strings.HasPrefix(p, "glob:"):
+			glob := strings.TrimPrefix(p, "glob:")
+			if _, err := path.Match(glob, ""); err != nil {
+				return nil, fmt.Errorf("invalid backwards compatible image glob %q: %w", glob, err)
+			}
+			rule.matches = func(img string) bool {
+				matched, _ := path.Match(glob, img)
+				return matched
+			}
+		case strings.HasPrefix(p, "regex:"):
+			re, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", strings.TrimPrefix(p, "regex:")))
+			if err != nil {
+				return nil, fmt.Errorf("invalid backwards compatible image regex %q: %w", p, err)
+			}
+			rule.matches = re.MatchString
+		default:
+			prefix := strings.TrimPrefix(p, "prefix:")
+			rule.matches = func(img string) bool {
+				return strings.HasPrefix(img, prefix)
+			}
+		}
+		c.rules = append(c.rules, rule)
+	}
+	return c, nil
+}
+
+// Classify returns the first pattern that matches the image name and true, or
+// an empty string and false if none of them match.
+func (c *BackwardsCompatClassifier) Classify(img string) (string, bool) {
+	for _, r := range c.rules {
+		if r.matches(img) {
+			return r.pattern, true
+		}
+	}
+	return "", false
+}
+
+// Patterns returns the patterns that the classifier was built from.
+func (c *BackwardsCompatClassifier) Patterns() []string {
+	patterns := make([]string, len(c.rules))
+	for i, r := range c.rules {
+		patterns[i] = r.pattern
+	}
+	return patterns
+}
+
+// defaultBackwardsCompat is compiled from DefaultBackwardsCompatPatterns and
+// never changes.
+var defaultBackwardsCompat = mustBackwardsCompatClassifier(DefaultBackwardsCompatPatterns)
+
+func mustBackwardsCompatClassifier(patterns []string) *BackwardsCompatClassifier {
+	c, err := NewBackwardsCompatClassifier(patterns)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// DefaultBackwardsCompat returns the classifier used by steps that haven't been
+// given one of their own.
+func DefaultBackwardsCompat() *BackwardsCompatClassifier {
+	return defaultBackwardsCompat
+}
+
+// SetBackwardsCompat sets the classifier used by the IsBackwardsCompatible()
+// method of each of the job's steps. A nil classifier restores the defaults.
+// The classifier isn't part of the job submission, so it isn't kept when the
+// job is encoded as JSON; clones keep it.
+func (job *Job) SetBackwardsCompat(c *BackwardsCompatClassifier) {
+	for i := range job.Steps {
+		job.Steps[i].backwardsCompat = c
+	}
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBackwardsCompatClassifier(t *testing.T) {
+	c, err := NewBackwardsCompatClassifier([]string{
+		"discoenv/backwards-compat",
+		"glob:harbor.cyverse.org/*/backwards-compat",
+		"regex:registry\\.example\\.org(:\\d+)?/legacy-.*",
+		"prefix:docker.cyverse.org/old-",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	images := map[string]string{
+		"discoenv/backwards-compat":                  "discoenv/backwards-compat",
+		"discoenv/backwards-compat-2":                "discoenv/backwards-compat",
+		"harbor.cyverse.org/legacy/backwards-compat": "glob:harbor.cyverse.org/*/backwards-compat",
+		"registry.example.org:5000/legacy-tools":     "regex:registry\\.example\\.org(:\\d+)?/legacy-.*",
+		"docker.cyverse.org/old-tools":               "prefix:docker.cyverse.org/old-",
+	}
+	for img, expected := range images {
+		actual, matched := c.Classify(img)
+		if !matched {
+			t.Errorf("Classify(%q) did not match", img)
+		}
+		if actual != expected {
+			t.Errorf("Classify(%q) returned '%s' instead of '%s'", img, actual, expected)
+		}
+	}
+	for _, img := range []string{"discoenv/test", "harbor.cyverse.org/a/b/backwards-compat", "x/registry.example.org/legacy-tools"} {
+		if actual, matched := c.Classify(img); matched {
+			t.Errorf("Classify(%q) matched '%s'", img, actual)
+		}
+	}
+
+	if _, err = NewBackwardsCompatClassifier([]string{"regex:("}); err == nil {
+		t.Error("NewBackwardsCompatClassifier() did not return an error for an invalid regex")
+	}
+	if _, err = NewBackwardsCompatClassifier([]string{"glob:["}); err == nil {
+		t.Error("NewBackwardsCompatClassifier() did not return an error for an invalid glob")
+	}
+}
+
+func TestWithBackwardsCompatPatterns(t *testing.T) {
+	data, err := JSONData("test/test_submission.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := NewJob(data, WithBackwardsCompatPatterns("glob:discoenv/*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if job.Steps[0].IsBackwardsCompatible() {
+		t.Error("IsBackwardsCompatible() returned true")
+	}
+	job.Steps[0].Component.Container.Image.Name = "discoenv/test"
+	pattern, matched := job.Steps[0].BackwardsCompatMatch()
+	if !matched || pattern != "glob:discoenv/*" {
+		t.Errorf("BackwardsCompatMatch() returned '%s', %t", pattern, matched)
+	}
+	pattern, matched = job.Clone().Steps[0].BackwardsCompatMatch()
+	if !matched || pattern != "glob:discoenv/*" {
+		t.Errorf("BackwardsCompatMatch() returned '%s', %t for a clone", pattern, matched)
+	}
+
+	// Other jobs still use the defaults.
+	s := _inittests(t, false)
+	if !s.Steps[0].IsBackwardsCompatible() {
+		t.Error("IsBackwardsCompatible() returned false for a job constructed without patterns")
+	}
+	actual := DefaultBackwardsCompat().Patterns()
+	if !reflect.DeepEqual(actual, DefaultBackwardsCompatPatterns) {
+		t.Errorf("Patterns() returned %#v instead of %#v", actual, DefaultBackwardsCompatPatterns)
+	}
+
+	if _, err = NewJob(data, WithBackwardsCompatPatterns("regex:(")); err == nil {
+		t.Error("NewJob() did not return an error for an invalid pattern")
+	}
+}
+
+func TestNewFromDataBackwardsCompat(t *testing.T) {
+	_inittests(t, false)
+	defer cfg.Set(BackwardsCompatConfigKey, nil)
+
+	cfg.Set(BackwardsCompatConfigKey, []string{"gims.iplantcollaborative.org:5000/"})
+	s := inittestsFile(t, "test/test_submission.json")
+	pattern, matched := s.Steps[0].BackwardsCompatMatch()
+	if !matched || pattern != "gims.iplantcollaborative.org:5000/" {
+		t.Errorf("BackwardsCompatMatch() returned '%s', %t", pattern, matched)
+	}
+
+	cfg.Set(BackwardsCompatConfigKey, []string{"regex:("})
+	data, err := JSONData("test/test_submission.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = NewFromData(cfg, data); err == nil {
+		t.Error("NewFromData() did not return an error for an invalid pattern")
+	}
+}
@@ -0,0 +1,158 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+)
+
+// DefaultMaxBatchSize is the largest number of child jobs that ExpandBatch will
+// create when BatchOptions.MaxJobs isn't set.
+const DefaultMaxBatchSize = 1000
+
+// BatchOptions controls how a batch of jobs is expanded from a template.
+type BatchOptions struct {
+	// The ID of the StepInput that receives each path. StepParams with the
+	// same ID receive the path's base name. Required.
+	InputID string
+
+	// The ID that all of the child jobs share. Defaults to the template's
+	// InvocationID.
+	BatchID string
+
+	// The maximum number of child jobs. Defaults to DefaultMaxBatchSize.
+	MaxJobs int
+
+	// Generates the children's InvocationIDs, and the batch ID if there isn't
+	// one. Defaults to NewUUID.
+	NewID IDGenerator
+}
+
+// setMetadata sets the value and unit of the AVU with the given attribute,
+// adding the AVU if it isn't present.
+func (job *Job) setMetadata(attr, value, unit string) {
+	for i, md := range job.FileMetadata {
+		if md.Attribute == attr {
+			job.FileMetadata[i].Value = value
+			job.FileMetadata[i].Unit = unit
+			return
+		}
+	}
+	job.FileMetadata = append(job.FileMetadata, FileMetadata{Attribute: attr, Value: value, Unit: unit})
+}
+
+// hasInput returns true if one of the job's steps has an input with the ID.
+func (job *Job) hasInput(id string) bool {
+	for _, input := range job.Inputs() {
+		if input.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// batchID returns the ID shared by the children of the job: the requested ID if
+// it's set, then the job's InvocationID, then a new ID from the generator.
+func (job *Job) batchID(requested string, gen IDGenerator) string {
+	if requested != "" {
+		return requested
+	}
+	if job.InvocationID != "" {
+		return job.InvocationID
+	}
+	return newID(gen)
+}
+
+// newChild returns a copy of the job to use as the index'th of count children
+// in a batch. The child is named after the job and its position, and gets a
+// new InvocationID from the generator. The caller sets the output directory.
+func (job *Job) newChild(batchID string, index, count int, gen IDGenerator) *Job {
+	width := len(fmt.Sprintf("%d", count))
+	child := job.deepCopy()
+	child.Name = sanitize(fmt.Sprintf("%s-%0*d", job.Name, width, index+1))
+	child.InvocationID = newID(gen)
+	child.BatchID = batchID
+	child.CreateOutputSubdir = false
+	child.InputPathListFile = ""
+	child.setMetadata("ipc-execution-id", child.InvocationID, "UUID")
+	return child
+}
+
+// ExpandBatch creates a child job for each of the paths from the template job,
+// substituting the path into the input identified by opts.InputID. The paths
+// are sorted and duplicates are removed, so the same paths always produce the
+// same children in the same order. Each child gets:
+//
+//   - a name made from the template's name and the child's position,
+//   - a new InvocationID and ipc-execution-id AVU,
+//   - the batch ID,
+//   - an output directory inside the template's output directory.
+//
+// The template isn't modified.
+func ExpandBatch(template *Job, paths []string, opts BatchOptions) ([]*Job, error) {
+	if opts.InputID == "" {
+		return nil, errors.New("the batch input ID is required")
+	}
+	if !template.hasInput(opts.InputID) {
+		return nil, fmt.Errorf("the template has no input with ID %s", opts.InputID)
+	}
+
+	sorted := make([]string, 0, len(paths))
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		if p == "" || seen[p] {
+			continue
+		}
+		seen[p] = true
+		sorted = append(sorted, p)
+	}
+	sort.Strings(sorted)
+	if len(sorted) == 0 {
+		return nil, errors.New("the batch has no input paths")
+	}
+
+	maxJobs := opts.MaxJobs
+	if maxJobs <= 0 {
+		maxJobs = DefaultMaxBatchSize
+	}
+	if len(sorted) > maxJobs {
+		return nil, fmt.Errorf("the batch would create %d jobs, which is more than the limit of %d", len(sorted), maxJobs)
+	}
+
+	batchID := template.batchID(opts.BatchID, opts.NewID)
+	parentDir := template.OutputDirectory()
+	children := make([]*Job, len(sorted))
+	for i, p := range sorted {
+		child := template.newChild(batchID, i, len(sorted), opts.NewID)
+		child.OutputDir = path.Join(parentDir, child.Name)
+
+		for s := range child.Steps {
+			config := &child.Steps[s].Config
+			for j := range config.Inputs {
+				if config.Inputs[j].ID == opts.InputID {
+					config.Inputs[j].Value = p
+					config.Inputs[j].Name = path.Base(p)
+				}
+			}
+			for j := range config.Params {
+				if config.Params[j].ID == opts.InputID {
+					config.Params[j].Value = path.Base(p)
+				}
+			}
+		}
+		children[i] = child
+	}
+	return children, nil
+}
+
+// ExpandBatchFromPathList works like ExpandBatch, reading the paths from a path
+// list in the format written by Job.WriteInputPathList().
+func ExpandBatchFromPathList(template *Job, r io.Reader, opts BatchOptions) ([]*Job, error) {
+	paths, err := ReadInputPathList(r)
+	if err != nil {
+		return nil, err
+	}
+	return ExpandBatch(template, paths, opts)
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"bytes"
+	"path"
+	"strings"
+	"testing"
+)
+
+const batchInputID = "2f58fce9-8183-4ab5-97c4-970592d1c35a"
+
+func TestExpandBatch(t *testing.T) {
+	s := _inittests(t, false)
+	paths := []string{
+		"/iplant/home/wregglej/c.txt",
+		"/iplant/home/wregglej/a.txt",
+		"/iplant/home/wregglej/b.txt",
+		"/iplant/home/wregglej/a.txt",
+	}
+	children, err := ExpandBatch(s, paths, BatchOptions{InputID: batchInputID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(children) != 3 {
+		t.Fatalf("ExpandBatch() returned %d jobs instead of 3", len(children))
+	}
+
+	seen := make(map[string]bool)
+	for i, child := range children {
+		expectedPath := paths[[]int{1, 2, 0}[i]]
+		expectedName := sanitize(s.Name) + "-" + string(rune('1'+i))
+		if child.Name != expectedName {
+			t.Errorf("child %d was named '%s' instead of '%s'", i, child.Name, expectedName)
+		}
+		if child.InvocationID == "" || child.InvocationID == s.InvocationID || seen[child.InvocationID] {
+			t.Errorf("child %d has InvocationID '%s', which isn't unique", i, child.InvocationID)
+		}
+		seen[child.InvocationID] = true
+		if child.BatchID != s.InvocationID {
+			t.Errorf("child %d has BatchID '%s' instead of '%s'", i, child.BatchID, s.InvocationID)
+		}
+		expectedDir := path.Join(s.OutputDirectory(), child.Name)
+		if child.OutputDirectory() != expectedDir {
+			t.Errorf("child %d has output directory '%s' instead of '%s'", i, child.OutputDirectory(), expectedDir)
+		}
+		if v := child.Steps[0].Config.Inputs[0].Value; v != expectedPath {
+			t.Errorf("child %d has input value '%s' instead of '%s'", i, v, expectedPath)
+		}
+		if v := child.Steps[0].Config.Params[1].Value; v != path.Base(expectedPath) {
+			t.Errorf("child %d has param value '%s' instead of '%s'", i, v, path.Base(expectedPath))
+		}
+		for _, md := range child.FileMetadata {
+			if md.Attribute == "ipc-execution-id" && md.Value != child.InvocationID {
+				t.Errorf("child %d has ipc-execution-id '%s' instead of '%s'", i, md.Value, child.InvocationID)
+			}
+		}
+	}
+
+	if s.Steps[0].Config.Inputs[0].Value != "/iplant/home/wregglej/Acer-tree.txt" {
+		t.Errorf("ExpandBatch() modified the template's inputs")
+	}
+	if s.Steps[0].Config.Params[1].Value != "Acer-tree.txt" {
+		t.Errorf("ExpandBatch() modified the template's params")
+	}
+}
+
+func TestExpandBatchPadding(t *testing.T) {
+	s := _inittests(t, false)
+	var paths []string
+	for i := 0; i < 12; i++ {
+		paths = append(paths, path.Join("/iplant/home/wregglej", strings.Repeat("x", i+1)))
+	}
+	children, err := ExpandBatch(s, paths, BatchOptions{InputID: batchInputID, BatchID: "batch"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(children[0].Name, "-01") || !strings.HasSuffix(children[11].Name, "-12") {
+		t.Errorf("ExpandBatch() returned names '%s' and '%s'", children[0].Name, children[11].Name)
+	}
+	if children[0].BatchID != "batch" {
+		t.Errorf("BatchID was '%s' instead of 'batch'", children[0].BatchID)
+	}
+}
+
+func TestExpandBatchErrors(t *testing.T) {
+	s := _inittests(t, false)
+	paths := []string{"/iplant/home/wregglej/a.txt", "/iplant/home/wregglej/b.txt"}
+	cases := []struct {
+		paths []string
+		opts  BatchOptions
+	}{
+		{paths, BatchOptions{}},
+		{paths, BatchOptions{InputID: "not-an-input"}},
+		{nil, BatchOptions{InputID: batchInputID}},
+		{[]string{""}, BatchOptions{InputID: batchInputID}},
+		{paths, BatchOptions{InputID: batchInputID, MaxJobs: 1}},
+	}
+	for i, c := range cases {
+		if _, err := ExpandBatch(s, c.paths, c.opts); err == nil {
+			t.Errorf("case %d: ExpandBatch() did not return an error", i)
+		}
+	}
+}
+
+func TestExpandBatchFromPathList(t *testing.T) {
+	s := _inittests(t, false)
+	var buf bytes.Buffer
+	if err := s.WriteInputPathList(&buf); err != nil {
+		t.Fatal(err)
+	}
+	children, err := ExpandBatchFromPathList(s, &buf, BatchOptions{InputID: batchInputID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(children) != 7 {
+		t.Errorf("ExpandBatchFromPathList() returned %d jobs instead of 7", len(children))
+	}
+}
@@ -0,0 +1,75 @@
+package model
+
+// copyStrings returns a copy of the slice, preserving nil.
+func copyStrings(s []string) []string {
+	if s == nil {
+		return nil
+	}
+	return append([]string{}, s...)
+}
+
+// deepCopy returns a copy of the step that doesn't share any slices or maps
+// with the original.
+func (s *Step) deepCopy() Step {
+	c := *s
+	if s.Environment != nil {
+		c.Environment = make(StepEnvironment, len(s.Environment))
+		for k, v := range s.Environment {
+			c.Environment[k] = v
+		}
+	}
+	if s.Input != nil {
+		c.Input = append([]StepInput{}, s.Input...)
+	}
+	if s.Output != nil {
+		c.Output = append([]StepOutput{}, s.Output...)
+	}
+	if s.Config.Params != nil {
+		c.Config.Params = append([]StepParam{}, s.Config.Params...)
+	}
+	if s.Config.Inputs != nil {
+		c.Config.Inputs = append([]StepInput{}, s.Config.Inputs...)
+	}
+	if s.Config.Outputs != nil {
+		c.Config.Outputs = append([]StepOutput{}, s.Config.Outputs...)
+	}
+
+	container := &c.Component.Container
+	if container.Volumes != nil {
+		container.Volumes = append([]Volume{}, container.Volumes...)
+	}
+	if container.Devices != nil {
+		container.Devices = append([]Device{}, container.Devices...)
+	}
+	if container.VolumesFrom != nil {
+		container.VolumesFrom = append([]VolumesFrom{}, container.VolumesFrom...)
+	}
+	if container.Ports != nil {
+		container.Ports = append([]Ports{}, container.Ports...)
+	}
+	return c
+}
+
+// deepCopy returns a copy of the job that doesn't share any slices or maps
+// with the original.
+func (job *Job) deepCopy() *Job {
+	c := *job
+	if job.FileMetadata != nil {
+		c.FileMetadata = append([]FileMetadata{}, job.FileMetadata...)
+	}
+	c.FilterFiles = copyStrings(job.FilterFiles)
+	c.UserGroups = copyStrings(job.UserGroups)
+	if job.Steps != nil {
+		c.Steps = make([]Step, len(job.Steps))
+		for i := range job.Steps {
+			c.Steps[i] = job.Steps[i].deepCopy()
+		}
+	}
+	return &c
+}
+
+// Clone returns a deep copy of the job. Changes made to the copy, including
+// its steps, parameters and metadata, don't affect the original.
+func (job *Job) Clone() *Job {
+	return job.deepCopy()
+}
@@ -0,0 +1,32 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestClone(t *testing.T) {
+	s := _inittests(t, false)
+	c := s.Clone()
+	if !reflect.DeepEqual(s, c) {
+		t.Fatal("Clone() returned a job that isn't equal to the original")
+	}
+
+	c.FileMetadata[0].Value = "changed"
+	c.FilterFiles[0] = "changed"
+	c.UserGroups[0] = "changed"
+	c.Steps[0].Environment["food"] = "changed"
+	c.Steps[0].Config.Params[0].Value = "changed"
+	c.Steps[0].Config.Inputs[0].Value = "changed"
+	c.Steps[0].Config.Outputs[0].Name = "changed"
+	c.Steps[0].Component.Container.Volumes[0].HostPath = "changed"
+	c.Steps[0].Component.Container.VolumesFrom[0].Name = "changed"
+	c.Steps[0].Component.Container.Devices[0].HostPath = "changed"
+	c.Steps[0].Component.Container.Ports[0].HostPort = 1
+
+	fresh := _inittests(t, false)
+	fresh.NowDate, fresh.SubmissionDate = s.NowDate, s.SubmissionDate
+	if !reflect.DeepEqual(s, fresh) {
+		t.Error("changing the clone modified the original job")
+	}
+}
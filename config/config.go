@@ -0,0 +1,56 @@
+// Package config builds the options for model.NewJob from a viper
+// configuration or from environment variables, so that the model package
+// itself doesn't have to read either.
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/cyverse-de/model/v8"
+	"github.com/spf13/viper"
+)
+
+// FromViper returns the constructor options for the settings in a viper
+// configuration. It's the same as model.ViperOptions(), which lists the
+// settings.
+func FromViper(cfg *viper.Viper) []model.Option {
+	return model.ViperOptions(cfg)
+}
+
+// envName converts a configuration key into the name of an environment
+// variable, the same way viper does with a "." to "_" key replacer.
+func envName(prefix, key string) string {
+	return prefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// FromEnv returns the constructor options for the settings in the environment.
+// The variables are named after the configuration keys read by FromViper,
+// upper-cased with the dots replaced by underscores and prepended with the
+// prefix, so with an empty prefix condor.log_path is read from CONDOR_LOG_PATH.
+// Lists are comma-separated. Variables that aren't set are skipped, as is a
+// backwards compatible pattern list without any patterns.
+func FromEnv(prefix string) []model.Option {
+	var opts []model.Option
+	if v, ok := os.LookupEnv(envName(prefix, model.LogPathConfigKey)); ok {
+		opts = append(opts, model.WithLogPath(v))
+	}
+	if v, ok := os.LookupEnv(envName(prefix, model.FilterFilesConfigKey)); ok {
+		opts = append(opts, model.WithFilterFiles(strings.Split(v, ",")...))
+	}
+	if v, ok := os.LookupEnv(envName(prefix, model.IRODSBaseConfigKey)); ok {
+		opts = append(opts, model.WithIRODSBase(v))
+	}
+	if v, ok := os.LookupEnv(envName(prefix, model.BackwardsCompatConfigKey)); ok {
+		var patterns []string
+		for _, p := range strings.Split(v, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				patterns = append(patterns, p)
+			}
+		}
+		if len(patterns) > 0 {
+			opts = append(opts, model.WithBackwardsCompatPatterns(patterns...))
+		}
+	}
+	return opts
+}
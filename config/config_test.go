@@ -0,0 +1,50 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cyverse-de/model/v8"
+	"github.com/spf13/viper"
+)
+
+func TestFromViper(t *testing.T) {
+	v := viper.New()
+	v.Set(model.LogPathConfigKey, "/logs")
+	v.Set(model.FilterFilesConfigKey, "foo,bar")
+	v.Set(model.IRODSBaseConfigKey, "/zone/home")
+	j, err := model.NewJob(nil, FromViper(v)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.CondorLogPath != "/logs" || j.IRODSBase != "/zone/home" || !reflect.DeepEqual(j.FilterFiles, []string{"foo", "bar"}) {
+		t.Errorf("FromViper() produced %q, %q and %#v", j.CondorLogPath, j.IRODSBase, j.FilterFiles)
+	}
+
+	v.Set(model.BackwardsCompatConfigKey, []string{"regex:("})
+	if _, err := model.NewJob(nil, FromViper(v)...); err == nil {
+		t.Error("NewJob() did not return an error for an invalid backwards compatible pattern")
+	}
+}
+
+func TestFromEnv(t *testing.T) {
+	t.Setenv("TEST_CONDOR_LOG_PATH", "/logs")
+	t.Setenv("TEST_CONDOR_FILTER_FILES", "foo,bar")
+	t.Setenv("TEST_IRODS_BASE", "/zone/home")
+	t.Setenv("TEST_CONDOR_BACKWARDS_COMPAT_PATTERNS", "")
+	opts := FromEnv("TEST_")
+	if len(opts) != 3 {
+		t.Fatalf("FromEnv() returned %d options instead of 3", len(opts))
+	}
+	j, err := model.NewJob(nil, opts...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.CondorLogPath != "/logs" || j.IRODSBase != "/zone/home" || !reflect.DeepEqual(j.FilterFiles, []string{"foo", "bar"}) {
+		t.Errorf("FromEnv() produced %q, %q and %#v", j.CondorLogPath, j.IRODSBase, j.FilterFiles)
+	}
+
+	if opts := FromEnv("UNSET_PREFIX_"); len(opts) != 0 {
+		t.Errorf("FromEnv() returned %d options for unset variables", len(opts))
+	}
+}
@@ -68,14 +68,21 @@ type Container struct {
 	Ports           []Ports         `json:"ports"`
 	SkipTmpMount    bool            `json:"skip_tmp_mount"`
 	UID             int             `json:"uid"`
+
+	layout *Layout // set by Job.SetLayout().
 }
 
-// WorkingDirectory returns the container's working directory. Defaults to
-// /de-app-work if the job submission didn't specify one. Use this function
-// rather than accessing the field directly.
+// DefaultWorkingDirectory is the working directory used by containers when the
+// job submission doesn't specify one.
+const DefaultWorkingDirectory = "/de-app-work"
+
+// WorkingDirectory returns the container's working directory. Defaults to the
+// working directory of the job's layout, /de-app-work unless the layout changes
+// it, if the job submission didn't specify one. Use this function rather than
+// accessing the field directly.
 func (c *Container) WorkingDirectory() string {
 	if c.WorkingDir == "" {
-		return "/de-app-work"
+		return c.layout.WorkingDirectory()
 	}
 	return c.WorkingDir
 }
@@ -0,0 +1,440 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CWLVersion is the version of the Common Workflow Language used for exported
+// documents.
+const CWLVersion = "v1.2"
+
+// mebibyte is the unit CWL uses for memory and disk space.
+const mebibyte = 1 << 20
+
+// CWLRequirement is a requirement or hint. The class field names the kind of
+// requirement and the other fields depend on it.
+type CWLRequirement map[string]any
+
+// CWLInputBinding describes how an input is placed on the command line.
+type CWLInputBinding struct {
+	Position *int   `json:"position,omitempty" yaml:"position,omitempty"`
+	Prefix   string `json:"prefix,omitempty" yaml:"prefix,omitempty"`
+	Separate *bool  `json:"separate,omitempty" yaml:"separate,omitempty"`
+}
+
+// CWLOutputBinding describes how an output is found after the tool runs.
+type CWLOutputBinding struct {
+	Glob string `json:"glob" yaml:"glob"`
+}
+
+// CWLParameter is an input or output of a tool or workflow. Type uses the CWL
+// shorthands, e.g. "string?" for an optional string and "File[]" for an array
+// of files.
+type CWLParameter struct {
+	ID            string            `json:"id" yaml:"id"`
+	Type          string            `json:"type" yaml:"type"`
+	Label         string            `json:"label,omitempty" yaml:"label,omitempty"`
+	Default       any               `json:"default,omitempty" yaml:"default,omitempty"`
+	InputBinding  *CWLInputBinding  `json:"inputBinding,omitempty" yaml:"inputBinding,omitempty"`
+	OutputBinding *CWLOutputBinding `json:"outputBinding,omitempty" yaml:"outputBinding,omitempty"`
+
+	// The step output that a workflow output comes from.
+	OutputSource string `json:"outputSource,omitempty" yaml:"outputSource,omitempty"`
+}
+
+// CWLTool is a CWL CommandLineTool document.
+type CWLTool struct {
+	CWLVersion   string           `json:"cwlVersion,omitempty" yaml:"cwlVersion,omitempty"`
+	Class        string           `json:"class" yaml:"class"`
+	ID           string           `json:"id,omitempty" yaml:"id,omitempty"`
+	Label        string           `json:"label,omitempty" yaml:"label,omitempty"`
+	Doc          string           `json:"doc,omitempty" yaml:"doc,omitempty"`
+	BaseCommand  []string         `json:"baseCommand,omitempty" yaml:"baseCommand,omitempty"`
+	Requirements []CWLRequirement `json:"requirements,omitempty" yaml:"requirements,omitempty"`
+	Inputs       []CWLParameter   `json:"inputs" yaml:"inputs"`
+	Outputs      []CWLParameter   `json:"outputs" yaml:"outputs"`
+	Stdin        string           `json:"stdin,omitempty" yaml:"stdin,omitempty"`
+	Stdout       string           `json:"stdout,omitempty" yaml:"stdout,omitempty"`
+	Stderr       string           `json:"stderr,omitempty" yaml:"stderr,omitempty"`
+}
+
+// CWLWorkflowStepInput connects an input of a workflow step to its source.
+type CWLWorkflowStepInput struct {
+	ID     string `json:"id" yaml:"id"`
+	Source string `json:"source" yaml:"source"`
+}
+
+// CWLWorkflowStep is a step of a workflow, with its tool embedded.
+type CWLWorkflowStep struct {
+	ID  string                 `json:"id" yaml:"id"`
+	Run *CWLTool               `json:"run" yaml:"run"`
+	In  []CWLWorkflowStepInput `json:"in" yaml:"in"`
+	Out []string               `json:"out" yaml:"out"`
+}
+
+// CWLWorkflow is a CWL Workflow document.
+type CWLWorkflow struct {
+	CWLVersion string            `json:"cwlVersion" yaml:"cwlVersion"`
+	Class      string            `json:"class" yaml:"class"`
+	ID         string            `json:"id,omitempty" yaml:"id,omitempty"`
+	Label      string            `json:"label,omitempty" yaml:"label,omitempty"`
+	Doc        string            `json:"doc,omitempty" yaml:"doc,omitempty"`
+	Inputs     []CWLParameter    `json:"inputs" yaml:"inputs"`
+	Outputs    []CWLParameter    `json:"outputs" yaml:"outputs"`
+	Steps      []CWLWorkflowStep `json:"steps" yaml:"steps"`
+}
+
+// unsafeCWLID matches the characters that aren't allowed in the identifiers
+// used in CWL parameter references.
+var unsafeCWLID = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// cwlIDs hands out identifiers that are unique within a document.
+type cwlIDs map[string]bool
+
+// next returns an identifier based on the name, or on the fallback if the name
+// has no usable characters.
+func (ids cwlIDs) next(name, fallback string) string {
+	id := strings.Trim(unsafeCWLID.ReplaceAllString(strings.TrimLeft(name, "-"), "_"), "_")
+	if id == "" {
+		id = fallback
+	}
+	if id[0] >= '0' && id[0] <= '9' {
+		id = "_" + id
+	}
+	unique := id
+	for i := 2; ids[unique]; i++ {
+		unique = fmt.Sprintf("%s_%d", id, i)
+	}
+	ids[unique] = true
+	return unique
+}
+
+// cwlType returns the CWL type for a parameter type. The second return value is
+// false for parameters that aren't passed to the tool.
+func cwlType(t ParamType) (string, bool) {
+	switch t {
+	case ParamTypeInfo:
+		return "", false
+	case ParamTypeInteger, ParamTypeIntegerSelection:
+		return "int", true
+	case ParamTypeDouble, ParamTypeDoubleSelection:
+		return "double", true
+	case ParamTypeFlag:
+		return "boolean", true
+	case ParamTypeFileInput, ParamTypeFileFolderInput:
+		return "File", true
+	case ParamTypeFolderInput:
+		return "Directory", true
+	case ParamTypeMultiFileSelector:
+		return "File[]", true
+	}
+	return "string", true
+}
+
+// cwlValue converts a parameter value into a CWL value of the type. Values that
+// don't parse are kept as strings.
+func cwlValue(cwlType, value string) any {
+	switch cwlType {
+	case "int":
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case "double":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "boolean":
+		if value == "" {
+			// Flags without a value are passed, like ParamRenderer does.
+			return true
+		}
+		if on, ok := parseFlag(value); ok {
+			return on
+		}
+	}
+	return value
+}
+
+// cwlFile returns a CWL File or Directory object for a path. Absolute paths are
+// in iRODS, so their locations are irods:// URIs; relative paths are left as
+// they are.
+func cwlFile(cfg *ExportConfig, class, p string) map[string]string {
+	location := p
+	if path.IsAbs(p) {
+		location = cfg.irodsURI(p)
+	}
+	return map[string]string{"class": class, "location": location}
+}
+
+// inputLocation returns the path of the input with the ID whose file name is
+// value, or value itself if there isn't one, and whether the input is a
+// collection.
+func (s *Step) inputLocation(id, value string) (string, bool) {
+	for _, input := range s.Config.Inputs {
+		if input.ID == id && (path.Base(input.Value) == value || input.Value == value) {
+			return input.Value, input.ParsedMultiplicity().IsCollection()
+		}
+	}
+	return value, false
+}
+
+// paramGroup is a parameter and the values of the multi-value parameters that
+// follow it.
+type paramGroup struct {
+	param  StepParam
+	values []string
+}
+
+// groupParams collects runs of parameters with the same ID where only the first
+// one has a name, the way ParamRenderer treats them.
+func groupParams(params []StepParam) []paramGroup {
+	var groups []paramGroup
+	for _, p := range params {
+		n := len(groups)
+		if n > 0 && strings.TrimSpace(p.Name) == "" && p.ID != "" && groups[n-1].param.ID == p.ID {
+			groups[n-1].values = append(groups[n-1].values, strings.TrimSpace(p.Value))
+			continue
+		}
+		groups = append(groups, paramGroup{param: p, values: []string{strings.TrimSpace(p.Value)}})
+	}
+	return groups
+}
+
+// CWLTool converts the step into a CWL CommandLineTool:
+//
+//   - the container image becomes a DockerRequirement,
+//   - the Min* and Max* container settings become a ResourceRequirement,
+//   - the environment and environment variable parameters become an
+//     EnvVarRequirement,
+//   - the parameters become inputs, bound to the command line in order, with
+//     their values as defaults,
+//   - inputs that aren't parameters are staged with an InitialWorkDirRequirement,
+//   - the defaults of file and directory inputs refer to the iRODS paths with
+//     irods:// URIs that use the host from cfg,
+//   - the outputs become outputs that glob for their names.
+//
+// A container EntryPoint becomes the base command, since CWL can't override an
+// image's entry point. CWL can't pass an option without a value, so typed
+// parameters with an empty value become optional inputs without a default,
+// which are left off the command line the way ParamRenderer leaves them out
+// when OmitEmpty is set.
+func (s *Step) CWLTool(cfg *ExportConfig) (*CWLTool, error) {
+	c := &s.Component.Container
+	ref, err := c.Image.Reference()
+	if err != nil {
+		return nil, err
+	}
+
+	tool := &CWLTool{
+		CWLVersion: CWLVersion,
+		Class:      "CommandLineTool",
+		Label:      s.Component.Name,
+		Doc:        s.Component.Description,
+		Stdin:      s.StdinPath,
+		Stdout:     s.StdoutPath,
+		Stderr:     s.StderrPath,
+		Inputs:     []CWLParameter{},
+		Outputs:    []CWLParameter{},
+	}
+	if c.EntryPoint != "" {
+		tool.BaseCommand = append(tool.BaseCommand, c.EntryPoint)
+	}
+	if e := strings.TrimSpace(s.Executable()); e != "" {
+		tool.BaseCommand = append(tool.BaseCommand, e)
+	}
+
+	docker := CWLRequirement{"class": "DockerRequirement", "dockerPull": ref.String()}
+	if c.WorkingDir != "" {
+		docker["dockerOutputDirectory"] = c.WorkingDir
+	}
+	tool.Requirements = append(tool.Requirements, docker)
+
+	resources := CWLRequirement{"class": "ResourceRequirement"}
+	if c.MinCPUCores > 0 {
+		resources["coresMin"] = c.MinCPUCores
+	}
+	if c.MaxCPUCores > 0 {
+		resources["coresMax"] = c.MaxCPUCores
+	}
+	if c.MinMemoryLimit > 0 {
+		resources["ramMin"] = (c.MinMemoryLimit + mebibyte - 1) / mebibyte
+	}
+	if c.MemoryLimit > 0 {
+		resources["ramMax"] = (c.MemoryLimit + mebibyte - 1) / mebibyte
+	}
+	if c.MinDiskSpace > 0 {
+		resources["outdirMin"] = (c.MinDiskSpace + mebibyte - 1) / mebibyte
+	}
+	if len(resources) > 1 {
+		tool.Requirements = append(tool.Requirements, resources)
+	}
+	if s.Component.TimeLimit > 0 {
+		tool.Requirements = append(tool.Requirements, CWLRequirement{"class": "ToolTimeLimit", "timelimit": s.Component.TimeLimit})
+	}
+
+	ids := make(cwlIDs)
+	env := make(map[string]string)
+	for k, v := range s.Environment {
+		env[k] = v
+	}
+	params := append([]StepParam{}, s.Config.Params...)
+	sort.Stable(ByOrder(params))
+	paramIDs := make(map[string]bool)
+	for position, g := range groupParams(params) {
+		p := g.param
+		paramIDs[p.ID] = true
+		t, ok := cwlType(p.ParsedType())
+		if !ok {
+			continue
+		}
+		name := strings.TrimSpace(p.Name)
+		value := g.values[0]
+		input := CWLParameter{ID: ids.next(name, "param"), Label: name}
+
+		if p.ParsedType() == ParamTypeEnvironmentVariable {
+			if name != "" {
+				input.Type = "string"
+				input.Default = value
+				env[name] = fmt.Sprintf("$(inputs.%s)", input.ID)
+				tool.Inputs = append(tool.Inputs, input)
+			}
+			continue
+		}
+
+		pos := position + 1
+		input.InputBinding = &CWLInputBinding{Position: &pos, Prefix: name}
+		if strings.HasSuffix(name, "=") {
+			separate := false
+			input.InputBinding.Separate = &separate
+		}
+
+		switch {
+		case p.Type == "" && value == "":
+			// An untyped parameter without a value is always passed as a flag.
+			input.Type = "boolean"
+			input.Default = true
+		case len(g.values) > 1 || strings.HasSuffix(t, "[]"):
+			item := strings.TrimSuffix(t, "[]")
+			input.Type = item + "[]"
+			var values []any
+			for _, v := range g.values {
+				if v != "" {
+					values = append(values, s.cwlDefault(cfg, p.ID, item, v))
+				}
+			}
+			if len(values) > 0 {
+				input.Default = values
+			} else {
+				input.Type += "?"
+			}
+		case value == "" && t != "boolean":
+			input.Type = t + "?"
+		default:
+			input.Type = t
+			input.Default = s.cwlDefault(cfg, p.ID, t, value)
+		}
+		tool.Inputs = append(tool.Inputs, input)
+	}
+
+	var staged []string
+	for _, in := range s.Config.Inputs {
+		if paramIDs[in.ID] || in.Value == "" {
+			continue
+		}
+		class := "File"
+		if in.ParsedMultiplicity().IsCollection() {
+			class = "Directory"
+		}
+		input := CWLParameter{
+			ID:      ids.next(in.Name, "input"),
+			Type:    class,
+			Default: cwlFile(cfg, class, in.Value),
+		}
+		staged = append(staged, fmt.Sprintf("$(inputs.%s)", input.ID))
+		tool.Inputs = append(tool.Inputs, input)
+	}
+	if len(staged) > 0 {
+		tool.Requirements = append(tool.Requirements, CWLRequirement{"class": "InitialWorkDirRequirement", "listing": staged})
+	}
+
+	if len(env) > 0 {
+		tool.Requirements = append(tool.Requirements, CWLRequirement{"class": "EnvVarRequirement", "envDef": env})
+	}
+
+	for _, out := range s.Config.Outputs {
+		t := "File"
+		switch {
+		case out.ParsedType() == ParamTypeMultiFileOutput:
+			t = "File[]"
+		case out.ParsedMultiplicity().IsCollection():
+			t = "Directory"
+		}
+		tool.Outputs = append(tool.Outputs, CWLParameter{
+			ID:            ids.next(out.Name, "output"),
+			Type:          t,
+			OutputBinding: &CWLOutputBinding{Glob: out.Name},
+		})
+	}
+	return tool, nil
+}
+
+// cwlDefault returns the default value for an input of the type. Files and
+// directories refer to the matching input's path.
+func (s *Step) cwlDefault(cfg *ExportConfig, id, t, value string) any {
+	if t == "File" || t == "Directory" {
+		location, collection := s.inputLocation(id, value)
+		if collection {
+			t = "Directory"
+		}
+		return cwlFile(cfg, t, location)
+	}
+	return cwlValue(t, value)
+}
+
+// CWLWorkflow converts the job into a CWL Workflow with a step for each of the
+// job's steps, embedding the tools returned by Step.CWLTool(). The inputs and
+// outputs of the steps are exposed as workflow inputs and outputs, prefixed
+// with the step's ID. The DE passes files between steps through the shared
+// working directory, which CWL doesn't have, so steps that read the files
+// written by earlier steps need their connections added by hand.
+func (job *Job) CWLWorkflow(cfg *ExportConfig) (*CWLWorkflow, error) {
+	wf := &CWLWorkflow{
+		CWLVersion: CWLVersion,
+		Class:      "Workflow",
+		Label:      job.AppName,
+		Doc:        job.AppDescription,
+		Inputs:     []CWLParameter{},
+		Outputs:    []CWLParameter{},
+		Steps:      []CWLWorkflowStep{},
+	}
+	for i := range job.Steps {
+		tool, err := job.Steps[i].CWLTool(cfg)
+		if err != nil {
+			return nil, fmt.Errorf("step %d: %w", i+1, err)
+		}
+		tool.CWLVersion = ""
+
+		stepID := fmt.Sprintf("step_%d", i+1)
+		step := CWLWorkflowStep{ID: stepID, Run: tool, In: []CWLWorkflowStepInput{}, Out: []string{}}
+		for _, in := range tool.Inputs {
+			id := fmt.Sprintf("%s_%s", stepID, in.ID)
+			wf.Inputs = append(wf.Inputs, CWLParameter{ID: id, Type: in.Type, Label: in.Label, Default: in.Default})
+			step.In = append(step.In, CWLWorkflowStepInput{ID: in.ID, Source: id})
+		}
+		for _, out := range tool.Outputs {
+			step.Out = append(step.Out, out.ID)
+			wf.Outputs = append(wf.Outputs, CWLParameter{
+				ID:           fmt.Sprintf("%s_%s", stepID, out.ID),
+				Type:         out.Type,
+				OutputSource: fmt.Sprintf("%s/%s", stepID, out.ID),
+			})
+		}
+		wf.Steps = append(wf.Steps, step)
+	}
+	return wf, nil
+}
@@ -0,0 +1,185 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+// cwlTestStep returns a step that exercises most of the CWL conversion.
+func cwlTestStep() *Step {
+	return &Step{
+		Component: StepComponent{
+			Name:        "align",
+			Description: "Aligns reads",
+			TimeLimit:   3600,
+			Container: Container{
+				Image:          ContainerImage{Name: "harbor.cyverse.org/de/aligner", Tag: "2.1"},
+				WorkingDir:     "/work",
+				MinCPUCores:    2,
+				MaxCPUCores:    4,
+				MinMemoryLimit: 3 * mebibyte / 2,
+				MemoryLimit:    8 * mebibyte,
+			},
+		},
+		Environment: StepEnvironment{"LANG": "C"},
+		Config: StepConfig{
+			Params: []StepParam{
+				{ID: "threads", Name: "--threads", Value: "4", Order: 1, Type: "Integer"},
+				{ID: "reads", Name: "--reads=", Value: "reads.fq", Order: 2, Type: "FileInput"},
+				{ID: "verbose", Name: "-v", Value: "true", Order: 3, Type: "Flag"},
+				{ID: "mode", Name: "--mode", Order: 4, Type: "TextSelection"},
+				{ID: "home", Name: "ALIGNER_HOME", Value: "/opt/aligner", Order: 5, Type: "EnvironmentVariable"},
+				{ID: "about", Name: "About", Order: 6, Type: "Info"},
+			},
+			Inputs: []StepInput{
+				{ID: "reads", Value: "/iplant/home/ipctest/reads.fq", Multiplicity: "single"},
+				{ID: "index", Name: "index", Value: "/iplant/home/ipctest/index", Multiplicity: "collection"},
+			},
+			Outputs: []StepOutput{
+				{Name: "aligned.bam", Multiplicity: "single"},
+				{Name: "reports", Multiplicity: "collection"},
+			},
+		},
+	}
+}
+
+// requirement returns the requirement with the class, or nil.
+func requirement(tool *CWLTool, class string) CWLRequirement {
+	for _, r := range tool.Requirements {
+		if r["class"] == class {
+			return r
+		}
+	}
+	return nil
+}
+
+func TestStepCWLTool(t *testing.T) {
+	tool, err := cwlTestStep().CWLTool(&ExportConfig{IRODSHost: "data.cyverse.org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tool.Class != "CommandLineTool" || tool.CWLVersion != CWLVersion || tool.Label != "align" {
+		t.Errorf("the tool's header was %s %s %s", tool.Class, tool.CWLVersion, tool.Label)
+	}
+
+	docker := requirement(tool, "DockerRequirement")
+	if docker["dockerPull"] != "harbor.cyverse.org/de/aligner:2.1" || docker["dockerOutputDirectory"] != "/work" {
+		t.Errorf("the DockerRequirement was %#v", docker)
+	}
+	expectedResources := CWLRequirement{
+		"class":    "ResourceRequirement",
+		"coresMin": float32(2),
+		"coresMax": float32(4),
+		"ramMin":   int64(2),
+		"ramMax":   int64(8),
+	}
+	if r := requirement(tool, "ResourceRequirement"); !reflect.DeepEqual(r, expectedResources) {
+		t.Errorf("the ResourceRequirement was %#v", r)
+	}
+	if r := requirement(tool, "ToolTimeLimit"); r["timelimit"] != 3600 {
+		t.Errorf("the ToolTimeLimit was %#v", r)
+	}
+	expectedEnv := map[string]string{"LANG": "C", "ALIGNER_HOME": "$(inputs.ALIGNER_HOME)"}
+	if r := requirement(tool, "EnvVarRequirement"); !reflect.DeepEqual(r["envDef"], expectedEnv) {
+		t.Errorf("the EnvVarRequirement was %#v", r)
+	}
+	if r := requirement(tool, "InitialWorkDirRequirement"); !reflect.DeepEqual(r["listing"], []string{"$(inputs.index)"}) {
+		t.Errorf("the InitialWorkDirRequirement was %#v", r)
+	}
+
+	one, two, three, four := 1, 2, 3, 4
+	separate := false
+	expectedInputs := []CWLParameter{
+		{ID: "threads", Type: "int", Label: "--threads", Default: int64(4), InputBinding: &CWLInputBinding{Position: &one, Prefix: "--threads"}},
+		{ID: "reads", Type: "File", Label: "--reads=", Default: map[string]string{"class": "File", "location": "irods://data.cyverse.org/iplant/home/ipctest/reads.fq"}, InputBinding: &CWLInputBinding{Position: &two, Prefix: "--reads=", Separate: &separate}},
+		{ID: "v", Type: "boolean", Label: "-v", Default: true, InputBinding: &CWLInputBinding{Position: &three, Prefix: "-v"}},
+		{ID: "mode", Type: "string?", Label: "--mode", InputBinding: &CWLInputBinding{Position: &four, Prefix: "--mode"}},
+		{ID: "ALIGNER_HOME", Type: "string", Label: "ALIGNER_HOME", Default: "/opt/aligner"},
+		{ID: "index", Type: "Directory", Default: map[string]string{"class": "Directory", "location": "irods://data.cyverse.org/iplant/home/ipctest/index"}},
+	}
+	if !reflect.DeepEqual(tool.Inputs, expectedInputs) {
+		t.Errorf("the inputs were\n%#v\ninstead of\n%#v", tool.Inputs, expectedInputs)
+	}
+
+	expectedOutputs := []CWLParameter{
+		{ID: "aligned_bam", Type: "File", OutputBinding: &CWLOutputBinding{Glob: "aligned.bam"}},
+		{ID: "reports", Type: "Directory", OutputBinding: &CWLOutputBinding{Glob: "reports"}},
+	}
+	if !reflect.DeepEqual(tool.Outputs, expectedOutputs) {
+		t.Errorf("the outputs were\n%#v\ninstead of\n%#v", tool.Outputs, expectedOutputs)
+	}
+
+	if _, err := yaml.Marshal(tool); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestCWLFileLocation(t *testing.T) {
+	cases := []struct {
+		cfg      *ExportConfig
+		path     string
+		expected string
+	}{
+		{&ExportConfig{IRODSHost: "data.cyverse.org"}, "/iplant/home/ipctest/reads.fq", "irods://data.cyverse.org/iplant/home/ipctest/reads.fq"},
+		{nil, "/iplant/home/ipctest/reads.fq", "irods:///iplant/home/ipctest/reads.fq"},
+		{&ExportConfig{IRODSHost: "data.cyverse.org"}, "reads.fq", "reads.fq"},
+	}
+	for _, c := range cases {
+		if actual := cwlFile(c.cfg, "File", c.path)["location"]; actual != c.expected {
+			t.Errorf("the location of %s was %s instead of %s", c.path, actual, c.expected)
+		}
+		if actual := irodsPathFromURI(c.expected); actual != c.path {
+			t.Errorf("irodsPathFromURI(%q) returned %s instead of %s", c.expected, actual, c.path)
+		}
+	}
+}
+
+func TestCWLIDs(t *testing.T) {
+	ids := make(cwlIDs)
+	cases := []struct{ name, expected string }{
+		{"--threads", "threads"},
+		{"--threads", "threads_2"},
+		{"-o=", "o"},
+		{"2nd", "_2nd"},
+		{"", "param"},
+		{"", "param_2"},
+	}
+	for _, c := range cases {
+		if actual := ids.next(c.name, "param"); actual != c.expected {
+			t.Errorf("next(%q) returned %s instead of %s", c.name, actual, c.expected)
+		}
+	}
+}
+
+func TestJobCWLWorkflow(t *testing.T) {
+	s := _inittests(t, false)
+	wf, err := s.CWLWorkflow(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wf.Class != "Workflow" || len(wf.Steps) != len(s.Steps) {
+		t.Fatalf("the workflow was %s with %d steps", wf.Class, len(wf.Steps))
+	}
+
+	step := wf.Steps[0]
+	if step.Run.CWLVersion != "" {
+		t.Error("the embedded tool has a cwlVersion")
+	}
+	if len(step.In) != len(step.Run.Inputs) || len(step.Out) != len(step.Run.Outputs) {
+		t.Errorf("the step has %d inputs and %d outputs", len(step.In), len(step.Out))
+	}
+	inputs := make(map[string]bool)
+	for _, in := range wf.Inputs {
+		inputs[in.ID] = true
+	}
+	for _, in := range step.In {
+		if !inputs[in.Source] {
+			t.Errorf("the step input %s refers to the missing workflow input %s", in.ID, in.Source)
+		}
+	}
+	if len(wf.Outputs) == 0 || wf.Outputs[0].OutputSource != "step_1/"+step.Out[0] {
+		t.Errorf("the workflow outputs were %#v", wf.Outputs)
+	}
+}
@@ -0,0 +1,707 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// cwlVersions are the CWL versions that ImportCWLTool accepts without a
+// warning.
+var cwlVersions = []string{"v1.0", "v1.1", "v1.2"}
+
+// cwlToolFields are the CommandLineTool fields that ImportCWLTool handles.
+// Other fields are reported as unsupported, except for metadata fields that
+// contain a namespace prefix or start with $.
+var cwlToolFields = map[string]bool{
+	"cwlVersion":   true,
+	"class":        true,
+	"id":           true,
+	"label":        true,
+	"doc":          true,
+	"baseCommand":  true,
+	"arguments":    true,
+	"requirements": true,
+	"hints":        true,
+	"inputs":       true,
+	"outputs":      true,
+	"stdin":        true,
+	"stdout":       true,
+	"stderr":       true,
+}
+
+// cwlInputFields are the input fields that ImportCWLTool handles.
+var cwlInputFields = map[string]bool{
+	"id":           true,
+	"type":         true,
+	"label":        true,
+	"doc":          true,
+	"default":      true,
+	"inputBinding": true,
+}
+
+// cwlImport holds the state of an import and the problems found so far.
+type cwlImport struct {
+	step        *Step
+	unsupported []string
+
+	// Inputs that aren't bound to the command line but are referenced by
+	// environment variables, keyed by input ID, and inputs that are staged into
+	// the working directory.
+	envInputs    map[string]string
+	stagedInputs map[string]bool
+}
+
+// unsupportedf records a feature that couldn't be converted.
+func (imp *cwlImport) unsupportedf(format string, args ...any) {
+	imp.unsupported = append(imp.unsupported, fmt.Sprintf(format, args...))
+}
+
+// inputReference matches a parameter reference to a whole input, which is how
+// CWLTool() refers to environment variable parameters and staged inputs.
+var inputReference = regexp.MustCompile(`^\$\(inputs\.([A-Za-z_][A-Za-z0-9_]*)\)$`)
+
+// isExpression returns true if the string contains a CWL parameter reference or
+// JavaScript expression.
+func isExpression(s string) bool {
+	return strings.Contains(s, "$(") || strings.Contains(s, "${")
+}
+
+// cwlString returns the value as a string, for scalars only.
+func cwlString(v any) (string, bool) {
+	switch t := v.(type) {
+	case string:
+		return t, true
+	case int:
+		return strconv.Itoa(t), true
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64), true
+	case bool:
+		return strconv.FormatBool(t), true
+	}
+	return "", false
+}
+
+// cwlNumber returns the value as a number. Expressions aren't numbers.
+func cwlNumber(v any) (float64, bool) {
+	switch t := v.(type) {
+	case int:
+		return float64(t), true
+	case float64:
+		return t, true
+	}
+	return 0, false
+}
+
+// cwlList normalizes the fields of a CWL document that may be written either as
+// a list of maps or as a map keyed by ID or class. Maps are converted into lists
+// sorted by key, with the key stored in the field named by keyField. A map
+// value that isn't a map is stored in the field named by valueField.
+func cwlList(v any, keyField, valueField string) ([]map[string]any, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		var list []map[string]any
+		for _, item := range t {
+			m, ok := item.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("expected a map but found %v", item)
+			}
+			list = append(list, m)
+		}
+		return list, nil
+	case map[string]any:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var list []map[string]any
+		for _, k := range keys {
+			m, ok := t[k].(map[string]any)
+			if !ok {
+				m = map[string]any{valueField: t[k]}
+			} else {
+				copied := make(map[string]any, len(m)+1)
+				for field, value := range m {
+					copied[field] = value
+				}
+				m = copied
+			}
+			m[keyField] = k
+			list = append(list, m)
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf("expected a list or a map but found %v", v)
+}
+
+// cwlLocalID strips the document and namespace parts from an identifier.
+func cwlLocalID(id string) string {
+	if i := strings.LastIndexAny(id, "#/"); i >= 0 {
+		return id[i+1:]
+	}
+	return id
+}
+
+// cwlInputType describes the type of a CWL input.
+type cwlInputType struct {
+	name     string // The CWL type, without ? or [].
+	optional bool
+	array    bool
+}
+
+// parseCWLType parses the shorthand and expanded forms of a CWL type.
+func parseCWLType(v any) (cwlInputType, error) {
+	var t cwlInputType
+	switch typ := v.(type) {
+	case string:
+		if strings.HasSuffix(typ, "?") {
+			t.optional = true
+			typ = strings.TrimSuffix(typ, "?")
+		}
+		if strings.HasSuffix(typ, "[]") {
+			t.array = true
+			typ = strings.TrimSuffix(typ, "[]")
+		}
+		t.name = typ
+	case []any:
+		// A union. Only optional types, i.e. unions with null, are supported.
+		var rest []any
+		for _, member := range typ {
+			if member == "null" {
+				t.optional = true
+			} else {
+				rest = append(rest, member)
+			}
+		}
+		if len(rest) != 1 {
+			return t, fmt.Errorf("union types are not supported")
+		}
+		inner, err := parseCWLType(rest[0])
+		if err != nil {
+			return t, err
+		}
+		inner.optional = inner.optional || t.optional
+		return inner, nil
+	case map[string]any:
+		switch typ["type"] {
+		case "array":
+			inner, err := parseCWLType(typ["items"])
+			if err != nil {
+				return t, err
+			}
+			if inner.array {
+				return t, fmt.Errorf("nested arrays are not supported")
+			}
+			inner.array = true
+			return inner, nil
+		case "enum":
+			t.name = "enum"
+		default:
+			return t, fmt.Errorf("%v types are not supported", typ["type"])
+		}
+	default:
+		return t, fmt.Errorf("the type %v is not supported", v)
+	}
+	return t, nil
+}
+
+// paramType returns the DE parameter type for the CWL type.
+func (t cwlInputType) paramType() (ParamType, error) {
+	switch t.name {
+	case "string", "Any":
+		return ParamTypeText, nil
+	case "int", "long":
+		return ParamTypeInteger, nil
+	case "float", "double":
+		return ParamTypeDouble, nil
+	case "boolean":
+		return ParamTypeFlag, nil
+	case "enum":
+		return ParamTypeTextSelection, nil
+	case "File":
+		if t.array {
+			return ParamTypeMultiFileSelector, nil
+		}
+		return ParamTypeFileInput, nil
+	case "Directory":
+		return ParamTypeFolderInput, nil
+	}
+	return "", fmt.Errorf("the type %s is not supported", t.name)
+}
+
+// cwlDefaultValues returns the default value of an input as strings, with files
+// and directories converted to their locations. The irods:// URIs written by
+// Step.CWLTool() are converted back to iRODS paths.
+func cwlDefaultValues(v any) ([]string, error) {
+	switch t := v.(type) {
+	case nil:
+		return nil, nil
+	case []any:
+		var values []string
+		for _, item := range t {
+			itemValues, err := cwlDefaultValues(item)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, itemValues...)
+		}
+		return values, nil
+	case map[string]any:
+		for _, field := range []string{"location", "path"} {
+			if location, ok := t[field].(string); ok {
+				return []string{irodsPathFromURI(location)}, nil
+			}
+		}
+		return nil, errors.New("file defaults without a location or path are not supported")
+	}
+	s, ok := cwlString(v)
+	if !ok {
+		return nil, fmt.Errorf("the default %v is not supported", v)
+	}
+	return []string{s}, nil
+}
+
+// cwlBoundParam is a parameter along with its CWL sort key.
+type cwlBoundParam struct {
+	position int
+	key      string
+	params   []StepParam
+}
+
+// ImportCWLTool builds a Step from a CWL CommandLineTool written in YAML or
+// JSON. It returns descriptions of the features of the tool that couldn't be
+// converted, such as expressions, requirements other than DockerRequirement,
+// ResourceRequirement, EnvVarRequirement, InitialWorkDirRequirement and
+// ToolTimeLimit, and inputs that aren't bound to the command line. Those
+// features are left out of the Step, so the caller should review it if any are
+// reported.
+//
+// Inputs become parameters, ordered the way CWL orders them on the command
+// line, and array inputs become multi-value parameters. File and Directory
+// inputs also become StepInputs with the same ID, using their defaults as the
+// values. Outputs with a literal glob become StepOutputs. Environment variables
+// and InitialWorkDirRequirement listings that refer to whole inputs, as
+// written by Step.CWLTool(), are converted too.
+func ImportCWLTool(data []byte) (*Step, []string, error) {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, nil, fmt.Errorf("unable to parse the CWL document: %w", err)
+	}
+	if doc["class"] != "CommandLineTool" {
+		return nil, nil, fmt.Errorf("the CWL document's class is %v, not CommandLineTool", doc["class"])
+	}
+
+	imp := &cwlImport{
+		step:         &Step{Type: string(StepTypeCondor)},
+		envInputs:    make(map[string]string),
+		stagedInputs: make(map[string]bool),
+	}
+	step := imp.step
+
+	version, _ := doc["cwlVersion"].(string)
+	known := false
+	for _, v := range cwlVersions {
+		known = known || v == version
+	}
+	if !known {
+		imp.unsupportedf("cwlVersion %q", version)
+	}
+
+	var fields []string
+	for field := range doc {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		if !cwlToolFields[field] && !strings.Contains(field, ":") && !strings.HasPrefix(field, "$") {
+			imp.unsupportedf("the %s field", field)
+		}
+	}
+
+	id, _ := doc["id"].(string)
+	step.Component.Name = cwlLocalID(id)
+	if label, ok := doc["label"].(string); ok && label != "" {
+		step.Component.Name = label
+	}
+	step.Component.Description, _ = doc["doc"].(string)
+
+	for _, field := range []string{"stdin", "stdout", "stderr"} {
+		value, ok := doc[field].(string)
+		if !ok {
+			continue
+		}
+		if isExpression(value) {
+			imp.unsupportedf("the expression in %s", field)
+			continue
+		}
+		switch field {
+		case "stdin":
+			step.StdinPath = value
+		case "stdout":
+			step.StdoutPath = value
+		case "stderr":
+			step.StderrPath = value
+		}
+	}
+
+	requirements, err := cwlList(doc["requirements"], "class", "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("requirements: %w", err)
+	}
+	hints, err := cwlList(doc["hints"], "class", "")
+	if err != nil {
+		return nil, nil, fmt.Errorf("hints: %w", err)
+	}
+	for _, r := range requirements {
+		imp.requirement(r, "requirement")
+	}
+	for _, h := range hints {
+		imp.requirement(h, "hint")
+	}
+	if step.Component.Container.Image.Name == "" {
+		imp.unsupportedf("running without a DockerRequirement")
+	}
+
+	var bound []cwlBoundParam
+	switch base := doc["baseCommand"].(type) {
+	case nil:
+	case string:
+		step.Component.Container.EntryPoint = base
+	case []any:
+		for i, arg := range base {
+			s, _ := cwlString(arg)
+			if i == 0 {
+				step.Component.Container.EntryPoint = s
+				continue
+			}
+			// The rest of the base command comes before all of the bound
+			// arguments.
+			bound = append(bound, cwlBoundParam{
+				position: math.MinInt,
+				key:      fmt.Sprintf("%04d", i),
+				params:   []StepParam{{Value: s}},
+			})
+		}
+	}
+
+	args, _ := doc["arguments"].([]any)
+	for i, arg := range args {
+		var position int
+		var prefix, value string
+		switch a := arg.(type) {
+		case map[string]any:
+			p, _ := cwlNumber(a["position"])
+			position = int(p)
+			prefix, _ = a["prefix"].(string)
+			value, _ = cwlString(a["valueFrom"])
+		default:
+			value, _ = cwlString(a)
+		}
+		if isExpression(value) {
+			imp.unsupportedf("the expression in argument %d", i+1)
+			continue
+		}
+		bound = append(bound, cwlBoundParam{
+			position: position,
+			key:      fmt.Sprintf("argument-%04d", i),
+			params:   []StepParam{{ID: fmt.Sprintf("argument-%d", i+1), Name: prefix, Value: value}},
+		})
+	}
+
+	inputs, err := cwlList(doc["inputs"], "id", "type")
+	if err != nil {
+		return nil, nil, fmt.Errorf("inputs: %w", err)
+	}
+	for _, in := range inputs {
+		b, ok := imp.input(in)
+		if ok {
+			bound = append(bound, b)
+		}
+	}
+
+	// CWL sorts the command line by position and then by the input's name.
+	sort.SliceStable(bound, func(i, j int) bool {
+		if bound[i].position != bound[j].position {
+			return bound[i].position < bound[j].position
+		}
+		return bound[i].key < bound[j].key
+	})
+	for _, b := range bound {
+		for _, p := range b.params {
+			p.Order = len(step.Config.Params)
+			step.Config.Params = append(step.Config.Params, p)
+		}
+	}
+
+	outputs, err := cwlList(doc["outputs"], "id", "type")
+	if err != nil {
+		return nil, nil, fmt.Errorf("outputs: %w", err)
+	}
+	for _, out := range outputs {
+		imp.output(out)
+	}
+	return step, imp.unsupported, nil
+}
+
+// requirement applies a requirement or hint to the step.
+func (imp *cwlImport) requirement(r map[string]any, kind string) {
+	c := &imp.step.Component.Container
+	class, _ := r["class"].(string)
+	switch cwlLocalID(class) {
+	case "DockerRequirement":
+		for field := range r {
+			switch field {
+			case "class", "dockerPull", "dockerOutputDirectory":
+			default:
+				imp.unsupportedf("%s in the DockerRequirement %s", field, kind)
+			}
+		}
+		if pull, ok := r["dockerPull"].(string); ok {
+			ref, err := ParseImageReference(pull)
+			if err != nil {
+				imp.unsupportedf("the image %q: %s", pull, err)
+				break
+			}
+			c.Image.Name = ref.Name()
+			if ref.Digest != "" {
+				c.Image.Name = fmt.Sprintf("%s@%s", ref.Name(), ref.Digest)
+			}
+			c.Image.Tag = ref.Tag
+		}
+		c.WorkingDir, _ = r["dockerOutputDirectory"].(string)
+
+	case "ResourceRequirement":
+		for field, value := range r {
+			if field == "class" {
+				continue
+			}
+			n, ok := cwlNumber(value)
+			if !ok {
+				imp.unsupportedf("the %s expression in the ResourceRequirement %s", field, kind)
+				continue
+			}
+			switch field {
+			case "coresMin":
+				c.MinCPUCores = float32(n)
+			case "coresMax":
+				c.MaxCPUCores = float32(n)
+			case "ramMin":
+				c.MinMemoryLimit = int64(n * mebibyte)
+			case "ramMax":
+				c.MemoryLimit = int64(n * mebibyte)
+			case "outdirMin":
+				c.MinDiskSpace = int64(n * mebibyte)
+			default:
+				imp.unsupportedf("%s in the ResourceRequirement %s", field, kind)
+			}
+		}
+
+	case "EnvVarRequirement":
+		defs, err := cwlList(r["envDef"], "envName", "envValue")
+		if err != nil {
+			imp.unsupportedf("the envDef in the EnvVarRequirement %s: %s", kind, err)
+			break
+		}
+		for _, def := range defs {
+			name, _ := def["envName"].(string)
+			value, _ := cwlString(def["envValue"])
+			if m := inputReference.FindStringSubmatch(value); m != nil {
+				imp.envInputs[m[1]] = name
+				continue
+			}
+			if isExpression(value) {
+				imp.unsupportedf("the expression in the environment variable %s", name)
+				continue
+			}
+			if imp.step.Environment == nil {
+				imp.step.Environment = make(StepEnvironment)
+			}
+			imp.step.Environment[name] = value
+		}
+
+	case "InitialWorkDirRequirement":
+		listing, _ := r["listing"].([]any)
+		for _, entry := range listing {
+			s, _ := entry.(string)
+			m := inputReference.FindStringSubmatch(s)
+			if m == nil {
+				imp.unsupportedf("the listing entry %v in the InitialWorkDirRequirement %s", entry, kind)
+				continue
+			}
+			imp.stagedInputs[m[1]] = true
+		}
+
+	case "ToolTimeLimit":
+		n, ok := cwlNumber(r["timelimit"])
+		if !ok {
+			imp.unsupportedf("the timelimit expression in the ToolTimeLimit %s", kind)
+			break
+		}
+		imp.step.Component.TimeLimit = int(n)
+
+	default:
+		imp.unsupportedf("the %s %s", class, kind)
+	}
+}
+
+// input converts an input into parameters and, for files and directories,
+// StepInputs. The second return value is false if the input isn't placed on
+// the command line.
+func (imp *cwlImport) input(in map[string]any) (cwlBoundParam, bool) {
+	id := cwlLocalID(fmt.Sprint(in["id"]))
+	for field := range in {
+		if !cwlInputFields[field] {
+			imp.unsupportedf("%s in the input %s", field, id)
+		}
+	}
+
+	binding, bound := in["inputBinding"].(map[string]any)
+	envName, isEnv := imp.envInputs[id]
+	if !bound && !isEnv && !imp.stagedInputs[id] {
+		imp.unsupportedf("the input %s, which isn't bound to the command line", id)
+		return cwlBoundParam{}, false
+	}
+	for field := range binding {
+		switch field {
+		case "position", "prefix", "separate":
+		default:
+			imp.unsupportedf("%s in the inputBinding of %s", field, id)
+		}
+	}
+
+	t, err := parseCWLType(in["type"])
+	if err != nil {
+		imp.unsupportedf("the type of %s: %s", id, err)
+		return cwlBoundParam{}, false
+	}
+	paramType, err := t.paramType()
+	if err != nil {
+		imp.unsupportedf("the type of %s: %s", id, err)
+		return cwlBoundParam{}, false
+	}
+
+	values, err := cwlDefaultValues(in["default"])
+	if err != nil {
+		imp.unsupportedf("the default of %s: %s", id, err)
+	}
+	if len(values) == 0 {
+		values = []string{""}
+	}
+
+	if isEnv && !bound {
+		p := StepParam{ID: id, Name: envName, Type: string(ParamTypeEnvironmentVariable), Value: values[0]}
+		return cwlBoundParam{key: id, params: []StepParam{p}}, true
+	}
+
+	prefix, _ := binding["prefix"].(string)
+	if separate, ok := binding["separate"].(bool); ok && !separate && prefix != "" && !strings.HasSuffix(prefix, "=") {
+		imp.unsupportedf("separate: false for %s, since the prefix doesn't end with =", id)
+	}
+
+	var params []StepParam
+	for i, value := range values {
+		p := StepParam{ID: id, Type: string(paramType), Value: value}
+		if i == 0 {
+			p.Name = prefix
+		}
+		if paramType.IsInput() {
+			multiplicity := MultiplicitySingle
+			switch {
+			case paramType == ParamTypeFolderInput:
+				multiplicity = MultiplicityCollection
+			case paramType == ParamTypeMultiFileSelector:
+				multiplicity = MultiplicityMany
+			}
+			label, _ := in["label"].(string)
+			imp.step.Config.Inputs = append(imp.step.Config.Inputs, StepInput{
+				ID:           id,
+				Name:         label,
+				Multiplicity: string(multiplicity),
+				Type:         string(paramType),
+				Value:        value,
+			})
+			if value != "" {
+				p.Value = lastPathElement(value)
+			}
+		}
+		params = append(params, p)
+	}
+	if !bound {
+		// Staged inputs are copied into the working directory, but aren't
+		// passed to the tool.
+		return cwlBoundParam{}, false
+	}
+
+	if t.optional && in["default"] == nil {
+		// CWL leaves optional inputs without a value off the command line,
+		// but ParamRenderer would still pass their names.
+		return cwlBoundParam{}, false
+	}
+
+	position, _ := cwlNumber(binding["position"])
+	return cwlBoundParam{position: int(position), key: id, params: params}, true
+}
+
+// lastPathElement returns the file name at the end of a path or URI.
+func lastPathElement(p string) string {
+	p = strings.TrimSuffix(p, "/")
+	if i := strings.LastIndex(p, "/"); i >= 0 {
+		return p[i+1:]
+	}
+	return p
+}
+
+// output converts an output into a StepOutput, or into the step's stdout or
+// stderr path.
+func (imp *cwlImport) output(out map[string]any) {
+	id := cwlLocalID(fmt.Sprint(out["id"]))
+	switch out["type"] {
+	case "stdout":
+		if imp.step.StdoutPath == "" {
+			imp.step.StdoutPath = id + ".stdout"
+		}
+		return
+	case "stderr":
+		if imp.step.StderrPath == "" {
+			imp.step.StderrPath = id + ".stderr"
+		}
+		return
+	}
+
+	t, err := parseCWLType(out["type"])
+	if err != nil || (t.name != "File" && t.name != "Directory") {
+		imp.unsupportedf("the output %s, which isn't a file or directory", id)
+		return
+	}
+	binding, _ := out["outputBinding"].(map[string]any)
+	glob, ok := binding["glob"].(string)
+	if !ok || isExpression(glob) || strings.ContainsAny(glob, "*?[") {
+		imp.unsupportedf("the output %s, which doesn't have a literal glob", id)
+		return
+	}
+	for field := range binding {
+		if field != "glob" {
+			imp.unsupportedf("%s in the outputBinding of %s", field, id)
+		}
+	}
+
+	o := StepOutput{Name: glob, Multiplicity: string(MultiplicitySingle), Type: string(ParamTypeFileOutput), Retain: true}
+	switch {
+	case t.name == "Directory":
+		o.Multiplicity = string(MultiplicityCollection)
+		o.Type = string(ParamTypeFolderOutput)
+	case t.array:
+		o.Multiplicity = string(MultiplicityMany)
+		o.Type = string(ParamTypeMultiFileOutput)
+	}
+	imp.step.Config.Outputs = append(imp.step.Config.Outputs, o)
+}
@@ -0,0 +1,202 @@
+package model
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+const testCWLTool = `
+cwlVersion: v1.2
+class: CommandLineTool
+id: sort-lines
+label: Sort lines
+doc: Sorts the lines of a file.
+baseCommand: [sort, --stable]
+arguments:
+  - -u
+  - valueFrom: $(inputs.input.nameroot)
+    position: 3
+requirements:
+  DockerRequirement:
+    dockerPull: alpine:3.19
+  ResourceRequirement:
+    coresMin: 1
+    ramMin: 512
+    outdirMin: $(inputs.input.size)
+  InlineJavascriptRequirement: {}
+hints:
+  - class: ToolTimeLimit
+    timelimit: 60
+  - class: EnvVarRequirement
+    envDef:
+      LC_ALL: C
+inputs:
+  reverse:
+    type: boolean
+    default: true
+    inputBinding:
+      prefix: -r
+      position: 1
+  key:
+    type: int?
+    inputBinding:
+      prefix: -k
+      position: 1
+  input:
+    type: File
+    default:
+      class: File
+      location: /iplant/home/ipctest/lines.txt
+    inputBinding:
+      position: 2
+    secondaryFiles: [.idx]
+  unused: string
+outputs:
+  sorted:
+    type: stdout
+  extra:
+    type: File
+    outputBinding:
+      glob: "*.txt"
+  summary:
+    type: File
+    outputBinding:
+      glob: summary.txt
+stdout: sorted.txt
+successCodes: [0, 1]
+s:author: someone
+`
+
+func TestImportCWLTool(t *testing.T) {
+	step, unsupported, err := ImportCWLTool([]byte(testCWLTool))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if step.Component.Name != "Sort lines" || step.Component.Description != "Sorts the lines of a file." {
+		t.Errorf("the component was %#v", step.Component)
+	}
+	c := step.Component.Container
+	if c.EntryPoint != "sort" || c.Image.Name != "docker.io/library/alpine" || c.Image.Tag != "3.19" {
+		t.Errorf("the container was %#v", c)
+	}
+	if c.MinCPUCores != 1 || c.MinMemoryLimit != 512*mebibyte || c.MinDiskSpace != 0 {
+		t.Errorf("the resources were %v, %d and %d", c.MinCPUCores, c.MinMemoryLimit, c.MinDiskSpace)
+	}
+	if step.Component.TimeLimit != 60 {
+		t.Errorf("the time limit was %d", step.Component.TimeLimit)
+	}
+	if !reflect.DeepEqual(step.Environment, StepEnvironment{"LC_ALL": "C"}) {
+		t.Errorf("the environment was %#v", step.Environment)
+	}
+	if step.StdoutPath != "sorted.txt" {
+		t.Errorf("the stdout path was %s", step.StdoutPath)
+	}
+
+	expectedArgs := []string{"--stable", "-u", "-r", "lines.txt"}
+	if args := step.Arguments(); !reflect.DeepEqual(args, expectedArgs) {
+		t.Errorf("the arguments were %#v instead of %#v", args, expectedArgs)
+	}
+	expectedInputs := []StepInput{{
+		ID:           "input",
+		Multiplicity: "single",
+		Type:         "FileInput",
+		Value:        "/iplant/home/ipctest/lines.txt",
+	}}
+	if !reflect.DeepEqual(step.Config.Inputs, expectedInputs) {
+		t.Errorf("the inputs were %#v", step.Config.Inputs)
+	}
+	expectedOutputs := []StepOutput{{
+		Name:         "summary.txt",
+		Multiplicity: "single",
+		Type:         "FileOutput",
+		Retain:       true,
+	}}
+	if !reflect.DeepEqual(step.Config.Outputs, expectedOutputs) {
+		t.Errorf("the outputs were %#v", step.Config.Outputs)
+	}
+
+	expectedUnsupported := []string{
+		"the successCodes field",
+		"the outdirMin expression in the ResourceRequirement requirement",
+		"the InlineJavascriptRequirement requirement",
+		"the expression in argument 2",
+		"secondaryFiles in the input input",
+		"the input unused, which isn't bound to the command line",
+		"the output extra, which doesn't have a literal glob",
+	}
+	for _, expected := range expectedUnsupported {
+		found := false
+		for _, u := range unsupported {
+			found = found || u == expected
+		}
+		if !found {
+			t.Errorf("%q wasn't reported in %#v", expected, unsupported)
+		}
+	}
+	if len(unsupported) != len(expectedUnsupported) {
+		t.Errorf("the unsupported features were %#v", unsupported)
+	}
+}
+
+func TestImportCWLToolErrors(t *testing.T) {
+	if _, _, err := ImportCWLTool([]byte("class: Workflow\ncwlVersion: v1.2\n")); err == nil {
+		t.Error("ImportCWLTool() accepted a workflow")
+	}
+	if _, _, err := ImportCWLTool([]byte("{")); err == nil {
+		t.Error("ImportCWLTool() accepted invalid YAML")
+	}
+	_, unsupported, err := ImportCWLTool([]byte(`{"class": "CommandLineTool", "cwlVersion": "draft-3", "inputs": [], "outputs": []}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	joined := strings.Join(unsupported, "\n")
+	if !strings.Contains(joined, `cwlVersion "draft-3"`) || !strings.Contains(joined, "DockerRequirement") {
+		t.Errorf("the unsupported features were %#v", unsupported)
+	}
+}
+
+func TestCWLRoundTrip(t *testing.T) {
+	original := cwlTestStep()
+	tool, err := original.CWLTool(&ExportConfig{IRODSHost: "data.cyverse.org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := yaml.Marshal(tool)
+	if err != nil {
+		t.Fatal(err)
+	}
+	step, unsupported, err := ImportCWLTool(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unsupported) != 0 {
+		t.Errorf("the round trip reported %#v", unsupported)
+	}
+
+	if args, expected := step.Arguments(), original.ArgumentsWith(&ParamRenderer{OmitEmpty: true}); !reflect.DeepEqual(args, expected) {
+		t.Errorf("the arguments were %#v instead of %#v", args, expected)
+	}
+	if env, expected := step.EnvironmentWithParams(), original.EnvironmentWithParams(); !reflect.DeepEqual(env, expected) {
+		t.Errorf("the environment was %#v instead of %#v", env, expected)
+	}
+	var values []string
+	for _, in := range step.Config.Inputs {
+		values = append(values, in.Value)
+	}
+	expectedValues := []string{"/iplant/home/ipctest/reads.fq", "/iplant/home/ipctest/index"}
+	if !reflect.DeepEqual(values, expectedValues) {
+		t.Errorf("the input values were %#v", values)
+	}
+
+	c, expected := step.Component.Container, original.Component.Container
+	if c.MinCPUCores != expected.MinCPUCores || c.MaxCPUCores != expected.MaxCPUCores || c.MemoryLimit != expected.MemoryLimit || c.WorkingDir != expected.WorkingDir {
+		t.Errorf("the container was %#v", c)
+	}
+	if step.Component.TimeLimit != original.Component.TimeLimit {
+		t.Errorf("the time limit was %d", step.Component.TimeLimit)
+	}
+}
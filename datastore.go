@@ -0,0 +1,220 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// DefaultDataStoreMountRoot is the directory in the container that the data
+// store is mounted under when DataStoreMountConfig.MountRoot isn't set.
+const DefaultDataStoreMountRoot = "/data-store"
+
+// DataStoreMountConfig contains the settings used to build a DataStoreMount.
+type DataStoreMountConfig struct {
+	// The FUSE or CSI driver that mounts the collections.
+	Driver string
+
+	// Driver-specific options, passed through unchanged.
+	Options map[string]string
+
+	// The directory in the container that collections are mounted under. Each
+	// collection is mounted at its iRODS path inside this directory. Defaults
+	// to DefaultDataStoreMountRoot.
+	MountRoot string
+
+	// The directory on the host where the driver has mounted the data store,
+	// used when rendering the mounts as docker volumes.
+	HostRoot string
+
+	// Collections that are mounted read-only for every job, such as the
+	// community data folders.
+	SharedPaths []string
+
+	// Mount the collections containing the job's inputs that aren't already
+	// covered by another mount.
+	IncludeInputs bool
+}
+
+// DataStoreCollection is a collection in the data store that's mounted into the
+// job's containers.
+type DataStoreCollection struct {
+	IRODSPath string `json:"irods_path"`
+	MountPath string `json:"mount_path"`
+	ReadOnly  bool   `json:"read_only"`
+
+	// Why the collection is mounted: home, shared, input or output.
+	Purpose string `json:"purpose"`
+}
+
+// DataStoreMount describes how the data store is mounted into a job's
+// containers when MountDataStore is set.
+type DataStoreMount struct {
+	Driver      string                `json:"driver"`
+	Options     map[string]string     `json:"options,omitempty"`
+	HostRoot    string                `json:"host_root,omitempty"`
+	Collections []DataStoreCollection `json:"collections"`
+}
+
+// The purposes of data store collections.
+const (
+	DataStorePurposeHome   = "home"
+	DataStorePurposeShared = "shared"
+	DataStorePurposeInput  = "input"
+	DataStorePurposeOutput = "output"
+)
+
+// covers returns true if one of the collections already provides access to p
+// that's at least as permissive as readOnly.
+func covers(collections []DataStoreCollection, p string, readOnly bool) bool {
+	for _, c := range collections {
+		if (p == c.IRODSPath || strings.HasPrefix(p, c.IRODSPath+"/")) && (readOnly || !c.ReadOnly) {
+			return true
+		}
+	}
+	return false
+}
+
+// DataStoreMount returns the collections to mount for the job: the user's
+// home directory and the shared paths read-only, the output directory
+// read-write, and optionally the collections containing the inputs read-only.
+// Collections inside another mount with the same or more access are skipped.
+// It returns nil if the job doesn't have MountDataStore set.
+func (job *Job) DataStoreMount(cfg DataStoreMountConfig) (*DataStoreMount, error) {
+	if !job.MountDataStore {
+		return nil, nil
+	}
+	if cfg.Driver == "" {
+		return nil, errors.New("a data store mount driver is required")
+	}
+	mountRoot := cfg.MountRoot
+	if mountRoot == "" {
+		mountRoot = DefaultDataStoreMountRoot
+	}
+
+	m := &DataStoreMount{Driver: cfg.Driver, Options: cfg.Options, HostRoot: cfg.HostRoot}
+	add := func(irodsPath string, readOnly bool, purpose string) error {
+		if !path.IsAbs(irodsPath) {
+			return fmt.Errorf("the %s collection %q is not an absolute path", purpose, irodsPath)
+		}
+		p := path.Clean(irodsPath)
+		if covers(m.Collections, p, readOnly) {
+			return nil
+		}
+		m.Collections = append(m.Collections, DataStoreCollection{
+			IRODSPath: p,
+			MountPath: path.Join(mountRoot, p),
+			ReadOnly:  readOnly,
+			Purpose:   purpose,
+		})
+		return nil
+	}
+
+	if job.UserHome != "" {
+		if err := add(job.UserHome, true, DataStorePurposeHome); err != nil {
+			return nil, err
+		}
+	}
+	for _, p := range cfg.SharedPaths {
+		if err := add(p, true, DataStorePurposeShared); err != nil {
+			return nil, err
+		}
+	}
+	if err := add(job.OutputDirectory(), false, DataStorePurposeOutput); err != nil {
+		return nil, err
+	}
+	if cfg.IncludeInputs {
+		for _, input := range job.Inputs() {
+			if input.Value == "" {
+				continue
+			}
+			collection := path.Dir(path.Clean(input.Value))
+			if input.ParsedMultiplicity().IsCollection() {
+				collection = path.Clean(input.Value)
+			}
+			if err := add(collection, true, DataStorePurposeInput); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Sorting puts parents before the collections nested inside them, so that
+	// nested mounts are applied last.
+	sort.Slice(m.Collections, func(i, j int) bool {
+		return m.Collections[i].MountPath < m.Collections[j].MountPath
+	})
+	return m, nil
+}
+
+// Volumes returns the collections as docker volumes, bound from the directory
+// where the driver mounted the data store on the host.
+func (m *DataStoreMount) Volumes() []Volume {
+	var volumes []Volume
+	for _, c := range m.Collections {
+		volumes = append(volumes, Volume{
+			HostPath:      path.Join(m.HostRoot, c.IRODSPath),
+			ContainerPath: c.MountPath,
+			ReadOnly:      c.ReadOnly,
+		})
+	}
+	return volumes
+}
+
+// KubernetesCSIVolumeSource mirrors the CSIVolumeSource type from the
+// Kubernetes API, so that it marshals into the same JSON.
+type KubernetesCSIVolumeSource struct {
+	Driver           string            `json:"driver"`
+	ReadOnly         *bool             `json:"readOnly,omitempty"`
+	VolumeAttributes map[string]string `json:"volumeAttributes,omitempty"`
+}
+
+// KubernetesVolume mirrors the parts of the Volume type from the Kubernetes
+// API that are used for data store mounts.
+type KubernetesVolume struct {
+	Name string                     `json:"name"`
+	CSI  *KubernetesCSIVolumeSource `json:"csi,omitempty"`
+}
+
+// KubernetesVolumeMount mirrors the VolumeMount type from the Kubernetes API.
+type KubernetesVolumeMount struct {
+	Name      string `json:"name"`
+	MountPath string `json:"mountPath"`
+	ReadOnly  bool   `json:"readOnly,omitempty"`
+}
+
+// DataStorePathAttribute is the CSI volume attribute that contains the iRODS
+// path of the collection to mount.
+const DataStorePathAttribute = "path"
+
+// KubernetesVolumes returns a CSI volume and a matching volume mount for each
+// collection. The driver options are passed as volume attributes, along with
+// the collection's path.
+func (m *DataStoreMount) KubernetesVolumes() ([]KubernetesVolume, []KubernetesVolumeMount) {
+	var volumes []KubernetesVolume
+	var mounts []KubernetesVolumeMount
+	for i, c := range m.Collections {
+		name := fmt.Sprintf("data-store-%d", i)
+		attrs := make(map[string]string, len(m.Options)+1)
+		for k, v := range m.Options {
+			attrs[k] = v
+		}
+		attrs[DataStorePathAttribute] = c.IRODSPath
+		readOnly := c.ReadOnly
+		volumes = append(volumes, KubernetesVolume{
+			Name: name,
+			CSI: &KubernetesCSIVolumeSource{
+				Driver:           m.Driver,
+				ReadOnly:         &readOnly,
+				VolumeAttributes: attrs,
+			},
+		})
+		mounts = append(mounts, KubernetesVolumeMount{
+			Name:      name,
+			MountPath: c.MountPath,
+			ReadOnly:  c.ReadOnly,
+		})
+	}
+	return volumes, mounts
+}
@@ -0,0 +1,94 @@
+package model
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+var testDataStoreConfig = DataStoreMountConfig{
+	Driver:        "irods.csi.cyverse.org",
+	Options:       map[string]string{"client": "irodsfuse"},
+	HostRoot:      "/mnt/irods",
+	SharedPaths:   []string{"/iplant/home/shared"},
+	IncludeInputs: true,
+}
+
+func TestDataStoreMount(t *testing.T) {
+	s := _inittests(t, false)
+	if m, err := s.DataStoreMount(testDataStoreConfig); m != nil || err != nil {
+		t.Errorf("DataStoreMount() returned %#v, %v without MountDataStore", m, err)
+	}
+
+	s.MountDataStore = true
+	m, err := s.DataStoreMount(testDataStoreConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []DataStoreCollection{
+		{"/iplant/home/shared", "/data-store/iplant/home/shared", true, DataStorePurposeShared},
+		{"/iplant/home/wregglej", "/data-store/iplant/home/wregglej", true, DataStorePurposeHome},
+		{s.OutputDirectory(), "/data-store" + s.OutputDirectory(), false, DataStorePurposeOutput},
+	}
+	if !reflect.DeepEqual(m.Collections, expected) {
+		t.Errorf("DataStoreMount() returned\n%#v\ninstead of\n%#v", m.Collections, expected)
+	}
+
+	s.Steps[0].Config.Inputs[0].Value = "/iplant/home/other/data/file.txt"
+	m, err = s.DataStoreMount(testDataStoreConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := m.Collections[0]
+	if input.IRODSPath != "/iplant/home/other/data" || !input.ReadOnly || input.Purpose != DataStorePurposeInput {
+		t.Errorf("DataStoreMount() didn't mount the input's collection: %#v", m.Collections)
+	}
+
+	if _, err = s.DataStoreMount(DataStoreMountConfig{}); err == nil {
+		t.Error("DataStoreMount() did not return an error without a driver")
+	}
+}
+
+func TestDataStoreMountVolumes(t *testing.T) {
+	s := _inittests(t, false)
+	s.MountDataStore = true
+	m, err := s.DataStoreMount(testDataStoreConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	volumes := m.Volumes()
+	if len(volumes) != 3 {
+		t.Fatalf("Volumes() returned %d volumes instead of 3", len(volumes))
+	}
+	expected := Volume{HostPath: "/mnt/irods/iplant/home/wregglej", ContainerPath: "/data-store/iplant/home/wregglej", ReadOnly: true}
+	if volumes[1] != expected {
+		t.Errorf("Volumes() returned %#v instead of %#v", volumes[1], expected)
+	}
+	if volumes[2].ReadOnly {
+		t.Error("the output directory was mounted read-only")
+	}
+}
+
+func TestDataStoreMountKubernetesVolumes(t *testing.T) {
+	s := _inittests(t, false)
+	s.MountDataStore = true
+	m, err := s.DataStoreMount(testDataStoreConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	volumes, mounts := m.KubernetesVolumes()
+	if len(volumes) != 3 || len(mounts) != 3 {
+		t.Fatalf("KubernetesVolumes() returned %d volumes and %d mounts", len(volumes), len(mounts))
+	}
+	data, err := json.Marshal(volumes[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"name":"data-store-1","csi":{"driver":"irods.csi.cyverse.org","readOnly":true,"volumeAttributes":{"client":"irodsfuse","path":"/iplant/home/wregglej"}}}`
+	if string(data) != expected {
+		t.Errorf("the volume was marshaled as\n%s\ninstead of\n%s", data, expected)
+	}
+	if mounts[2].Name != volumes[2].Name || mounts[2].ReadOnly {
+		t.Errorf("KubernetesVolumes() returned the mount %#v", mounts[2])
+	}
+}
@@ -0,0 +1,198 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ExcludeList is a deduplicated list of paths and glob patterns that shouldn't
+// be uploaded as outputs. Entries are relative to the working directory, and
+// collections always end with a single slash.
+//
+// Entries are matched much like the entries in a .gitignore file. An entry
+// that doesn't contain a slash (other than the trailing slash of a collection)
+// is matched against every component of a path, so "*.tmp" excludes temporary
+// files at any depth. Other entries are matched from the top of the working
+// directory. Entries without a trailing slash match files and directories by
+// name, the way porklock excludes them; collection entries only match
+// directories. Either way, everything in a matching directory is excluded.
+type ExcludeList struct {
+	entries []string
+	seen    map[string]bool
+	workDir string // paths inside this directory are made relative to it.
+}
+
+// NewExcludeList returns an ExcludeList containing the normalized paths.
+func NewExcludeList(paths ...string) *ExcludeList {
+	return newExcludeList(DefaultLayout.WorkDir, paths...)
+}
+
+// newExcludeList returns an ExcludeList that treats workDir as the working
+// directory.
+func newExcludeList(workDir string, paths ...string) *ExcludeList {
+	e := &ExcludeList{seen: make(map[string]bool), workDir: path.Clean(workDir)}
+	for _, p := range paths {
+		e.Add(p)
+	}
+	return e
+}
+
+// normalizeExclude trims whitespace, makes paths inside the working directory
+// relative to it and collapses repeated trailing slashes. An empty string is
+// returned for entries that should be dropped.
+func (e *ExcludeList) normalizeExclude(p string) string {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return ""
+	}
+	collection := strings.HasSuffix(p, "/")
+	if strings.HasPrefix(p, e.workDir+"/") {
+		p = strings.TrimPrefix(p, e.workDir+"/")
+	}
+	p = path.Clean(p)
+	if p == "." || p == "/" {
+		return ""
+	}
+	if collection {
+		p += "/"
+	}
+	return p
+}
+
+// Add adds a path or pattern to the list if it isn't already present.
+func (e *ExcludeList) Add(p string) {
+	if p = e.normalizeExclude(p); p == "" || e.seen[p] {
+		return
+	}
+	e.seen[p] = true
+	e.entries = append(e.entries, p)
+}
+
+// Entries returns the entries in the order in which they were added.
+func (e *ExcludeList) Entries() []string {
+	entries := make([]string, len(e.entries))
+	copy(entries, e.entries)
+	return entries
+}
+
+// isPattern returns true if the entry contains glob metacharacters.
+func isPattern(entry string) bool {
+	return strings.ContainsAny(entry, "*?[")
+}
+
+// matchExclude returns true if the entry excludes the path. The path must be
+// cleaned and relative to the working directory; dir indicates whether the
+// path itself is a directory.
+func matchExclude(entry, p string, dir bool) bool {
+	collection := strings.HasSuffix(entry, "/")
+	entry = strings.TrimSuffix(entry, "/")
+	components := strings.Split(p, "/")
+
+	// matchesAt returns true if the entry matches the path up to and including
+	// the component at index i, and that prefix is a directory if the entry is
+	// a collection.
+	matchesAt := func(i int, prefix string) bool {
+		isDir := dir || i < len(components)-1
+		return (!collection || isDir) && entryMatches(entry, prefix)
+	}
+
+	// Unanchored entries may match any component of the path.
+	if !strings.Contains(entry, "/") {
+		for i, c := range components {
+			if matchesAt(i, c) {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Anchored entries match from the top of the working directory.
+	depth := len(strings.Split(entry, "/"))
+	if len(components) < depth {
+		return false
+	}
+	return matchesAt(depth-1, strings.Join(components[:depth], "/"))
+}
+
+// entryMatches compares an entry with a path, treating entries that contain
+// glob metacharacters as patterns.
+func entryMatches(entry, p string) bool {
+	if !isPattern(entry) {
+		return entry == p
+	}
+	matched, err := path.Match(entry, p)
+	return err == nil && matched
+}
+
+// Matches returns true if the path would be excluded from the upload. The path
+// may be relative to the working directory or absolute within it. Directories
+// must end with a slash, like collection entries.
+func (e *ExcludeList) Matches(p string) bool {
+	p = e.normalizeExclude(p)
+	dir := strings.HasSuffix(p, "/")
+	if p = strings.TrimSuffix(p, "/"); p == "" {
+		return false
+	}
+	for _, entry := range e.entries {
+		if matchExclude(entry, p, dir) {
+			return true
+		}
+	}
+	return false
+}
+
+// Expand returns a new ExcludeList in which every pattern has been replaced by
+// the paths in localPaths that it matches. Directories in localPaths must end
+// with a slash. This is useful for transfer tools that don't support patterns.
+// Entries that aren't patterns are kept as-is.
+func (e *ExcludeList) Expand(localPaths []string) *ExcludeList {
+	expanded := newExcludeList(e.workDir)
+	for _, entry := range e.entries {
+		if !isPattern(entry) {
+			expanded.Add(entry)
+			continue
+		}
+		single := newExcludeList(e.workDir, entry)
+		for _, lp := range localPaths {
+			if single.Matches(lp) {
+				expanded.Add(lp)
+			}
+		}
+	}
+	return expanded
+}
+
+// ExcludeDelimiter separates the entries in an exclude file.
+const ExcludeDelimiter = "\n"
+
+// WriteTo writes the list in the format that porklock reads from the file passed
+// to its --exclude option, with one entry per line. Patterns are written as-is;
+// use Expand() first if the reader doesn't support them.
+func (e *ExcludeList) WriteTo(w io.Writer) (int64, error) {
+	var total int64
+	for _, entry := range e.entries {
+		n, err := fmt.Fprintf(w, "%s%s", entry, ExcludeDelimiter)
+		total += int64(n)
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// ExcludeList returns the paths that shouldn't be uploaded as outputs as an
+// ExcludeList. It contains the same entries as ExcludeArguments(), normalized
+// and deduplicated, with the logs directory marked as a collection.
+func (job *Job) ExcludeList() *ExcludeList {
+	e := newExcludeList(job.layout.WorkingDirectory())
+	logsDir := job.layout.LogsDirectory()
+	for _, p := range job.ExcludeArguments() {
+		if !job.ArchiveLogs && p == logsDir {
+			p = logsDir + "/"
+		}
+		e.Add(p)
+	}
+	return e
+}
@@ -0,0 +1,88 @@
+package model
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestNewExcludeList(t *testing.T) {
+	e := NewExcludeList("foo", " foo ", "", "/de-app-work/logs/", "logs//", "dir/../bar", "*.tmp")
+	actual := e.Entries()
+	expected := []string{"foo", "logs/", "bar", "*.tmp"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Entries() returned %#v instead of %#v", actual, expected)
+	}
+}
+
+func TestExcludeListMatches(t *testing.T) {
+	e := NewExcludeList("Acer-tree.txt", "logs/", "*.tmp", "core.*", "data/raw/", ".irods")
+	paths := map[string]bool{
+		"Acer-tree.txt":              true,
+		"/de-app-work/logs/":         true,
+		"/de-app-work/logs":          false,
+		"logs/condor-stdout-0":       true,
+		"output/scratch.tmp":         true,
+		"core.1234":                  true,
+		"data/raw/reads.fq":          true,
+		"data/raw/":                  true,
+		"data/raw":                   false,
+		"Acer-tree.txt/":             true,
+		"Acer-tree.txt/notes":        true,
+		"scratch.tmp/":               true,
+		".irods/":                    true,
+		".irods/irods_environment":   true,
+		"sub/.irods/irods_env":       true,
+		"other/data/raw/reads.fq":    false,
+		"data/rawer":                 false,
+		"wc_out.txt":                 false,
+		"Acer-tree.txt.bak":          false,
+		"output/core":                false,
+		"/de-app-work/wc_out.txt":    false,
+		"/de-app-work/Acer-tree.txt": true,
+	}
+	for p, expected := range paths {
+		if actual := e.Matches(p); actual != expected {
+			t.Errorf("Matches(%q) returned %t instead of %t", p, actual, expected)
+		}
+	}
+}
+
+func TestExcludeListExpand(t *testing.T) {
+	e := NewExcludeList("foo", "*.tmp")
+	actual := e.Expand([]string{"a.tmp", "b.txt", "dir/c.tmp", "a.tmp"}).Entries()
+	expected := []string{"foo", "a.tmp", "dir/c.tmp"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expand() returned %#v instead of %#v", actual, expected)
+	}
+}
+
+func TestExcludeListWriteTo(t *testing.T) {
+	e := NewExcludeList("foo", "logs/", "*.tmp")
+	var buf bytes.Buffer
+	n, err := e.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "foo\nlogs/\n*.tmp\n"
+	if buf.String() != expected {
+		t.Errorf("WriteTo() wrote %q instead of %q", buf.String(), expected)
+	}
+	if n != int64(len(expected)) {
+		t.Errorf("WriteTo() returned %d instead of %d", n, len(expected))
+	}
+}
+
+func TestJobExcludeList(t *testing.T) {
+	s := _inittests(t, false)
+	s.FilterFiles = append(s.FilterFiles, "foo", "*.tmp")
+	s.Steps[0].Config.Inputs[0].Retain = false
+	s.Steps[0].Config.Outputs[1].Retain = false
+	s.ArchiveLogs = false
+	actual := s.ExcludeList().Entries()
+	expected := []string{"Acer-tree.txt", "logs/", "foo", "bar", "baz", "blippy", "*.tmp"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ExcludeList() returned %#v instead of %#v", actual, expected)
+	}
+	_inittests(t, false)
+}
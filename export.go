@@ -0,0 +1,201 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+)
+
+// The job states that appear in a status timeline.
+const (
+	StatusSubmitted = "Submitted"
+	StatusRunning   = "Running"
+	StatusCompleted = "Completed"
+	StatusFailed    = "Failed"
+	StatusCanceled  = "Canceled"
+)
+
+// StatusUpdate is an entry in a job's status timeline.
+type StatusUpdate struct {
+	State   string    `json:"state"`
+	Message string    `json:"message,omitempty"`
+	SentOn  time.Time `json:"sent_on"`
+}
+
+// isFinal returns true if the state ends a job.
+func isFinal(state string) bool {
+	return state == StatusCompleted || state == StatusFailed || state == StatusCanceled
+}
+
+// ExportConfig contains the settings used when exporting a job's provenance.
+type ExportConfig struct {
+	// The host of the iRODS server, used in the irods:// URIs that identify
+	// the inputs and outputs. The URIs have an empty host if it isn't set.
+	IRODSHost string
+}
+
+// irodsURI returns the URI identifying an iRODS path.
+func (c *ExportConfig) irodsURI(p string) string {
+	var host string
+	if c != nil {
+		host = c.IRODSHost
+	}
+	return fmt.Sprintf("irods://%s%s", host, p)
+}
+
+// irodsPathFromURI returns the iRODS path in an irods:// URI. Anything else is
+// returned unchanged.
+func irodsPathFromURI(uri string) string {
+	rest, ok := strings.CutPrefix(uri, "irods://")
+	if !ok {
+		return uri
+	}
+	if i := strings.Index(rest, "/"); i >= 0 {
+		return rest[i:]
+	}
+	return "/"
+}
+
+// exportFile is an input or output of an exported job.
+type exportFile struct {
+	uri        string
+	path       string
+	name       string
+	collection bool
+}
+
+// exportParam is a parameter of one of the steps of an exported job.
+type exportParam struct {
+	id    string // Unique within the export.
+	param StepParam
+}
+
+// exportTool is the component run by one of the steps of an exported job.
+type exportTool struct {
+	id        string
+	component StepComponent
+	image     string
+}
+
+// exportRecord collects what the exporters describe about a job.
+type exportRecord struct {
+	inputs  []exportFile
+	outputs []exportFile
+	params  []exportParam
+	tools   []exportTool
+	images  []string
+
+	submitted time.Time
+	started   time.Time
+	completed time.Time
+	state     string
+}
+
+// exportRecord gathers the inputs, outputs, parameters, tools and timestamps of
+// the job. The DateSubmitted, DateStarted and DateCompleted fields take
+// precedence; the timeline fills in the ones that aren't set and provides the
+// final state.
+func (job *Job) exportRecord(timeline []StatusUpdate, cfg *ExportConfig) (*exportRecord, error) {
+	r := &exportRecord{
+		submitted: job.DateSubmitted,
+		started:   job.DateStarted,
+		completed: job.DateCompleted,
+	}
+
+	// The paths come from PathMappings() so that the record agrees with what's
+	// transferred; outputs that aren't uploaded have no iRODS path.
+	seenFiles := make(map[string]bool)
+	for _, m := range job.PathMappings("") {
+		p := m.IRODSPath
+		if p == "" {
+			continue
+		}
+		if m.IsCollection && !strings.HasSuffix(p, "/") {
+			p += "/"
+		}
+		if seenFiles[p] {
+			continue
+		}
+		seenFiles[p] = true
+		f := exportFile{
+			uri:        cfg.irodsURI(p),
+			path:       p,
+			name:       path.Base(p),
+			collection: m.IsCollection,
+		}
+		if m.IsInput {
+			r.inputs = append(r.inputs, f)
+		} else {
+			r.outputs = append(r.outputs, f)
+		}
+	}
+
+	seenImages := make(map[string]bool)
+	seenParams := make(map[string]bool)
+	for i, step := range job.Steps {
+		params := append([]StepParam{}, step.Config.Params...)
+		sort.Stable(ByOrder(params))
+		for j, p := range params {
+			r.params = append(r.params, exportParam{
+				id:    exportParamID(seenParams, i+1, j+1, p.ID),
+				param: p,
+			})
+		}
+
+		ref, err := step.Component.Container.Image.Reference()
+		if err != nil {
+			return nil, err
+		}
+		image := ref.String()
+		if !seenImages[image] {
+			seenImages[image] = true
+			r.images = append(r.images, image)
+		}
+		r.tools = append(r.tools, exportTool{
+			id:        fmt.Sprintf("step-%d", i+1),
+			component: step.Component,
+			image:     image,
+		})
+	}
+
+	for _, update := range timeline {
+		if r.submitted.IsZero() {
+			r.submitted = update.SentOn
+		}
+		if r.started.IsZero() && update.State == StatusRunning {
+			r.started = update.SentOn
+		}
+		if isFinal(update.State) && job.DateCompleted.IsZero() {
+			r.completed = update.SentOn
+		}
+		r.state = update.State
+	}
+	return r, nil
+}
+
+// exportParamID returns an identifier for a parameter that's unique within the
+// record. It's based on the parameter's ID, or on its position in the step if
+// it doesn't have one; parameters that repeat an ID get a numeric suffix.
+func exportParamID(seen map[string]bool, step, index int, paramID string) string {
+	id := fmt.Sprintf("step-%d-param-%s", step, paramID)
+	if paramID == "" {
+		id = fmt.Sprintf("step-%d-param-%d", step, index)
+	}
+	unique := id
+	for n := 2; seen[unique]; n++ {
+		unique = fmt.Sprintf("%s-%d", id, n)
+	}
+	seen[unique] = true
+	return unique
+}
+
+// exportTime formats a timestamp for the exported documents. Zero times are
+// returned as an empty string.
+func exportTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,127 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportRecordOutputPaths(t *testing.T) {
+	s := _inittests(t, false)
+	dir := s.OutputDirectory()
+	s.Steps = s.Steps[:1]
+	s.Steps[0].Component.Container.WorkingDir = "/step-work"
+	s.Steps[0].Config.Outputs = []StepOutput{
+		{Name: "wc_out.txt", Multiplicity: "single"},
+		{Name: "logs", Multiplicity: "collection"},
+		{Name: "/step-work/results/out.txt"},
+		{Name: "/elsewhere/out.txt"},
+	}
+	r, err := s.exportRecord(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var actual []string
+	for _, o := range r.outputs {
+		actual = append(actual, o.path)
+	}
+	expected := []string{dir + "/wc_out.txt", dir + "/logs/", dir + "/results/out.txt"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("the output paths were %v instead of %v", actual, expected)
+	}
+}
+
+func TestExportRecordTimeline(t *testing.T) {
+	s := _inittests(t, false)
+	submitted := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	timeline := []StatusUpdate{
+		{State: StatusSubmitted, SentOn: submitted},
+		{State: StatusRunning, SentOn: submitted.Add(time.Minute)},
+		{State: StatusRunning, Message: "still running", SentOn: submitted.Add(2 * time.Minute)},
+		{State: StatusCompleted, SentOn: submitted.Add(time.Hour)},
+	}
+
+	r, err := s.exportRecord(timeline, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.submitted.Equal(submitted) {
+		t.Errorf("the submission time was %s", r.submitted)
+	}
+	if !r.started.Equal(submitted.Add(time.Minute)) {
+		t.Errorf("the start time was %s", r.started)
+	}
+	if !r.completed.Equal(submitted.Add(time.Hour)) {
+		t.Errorf("the completion time was %s", r.completed)
+	}
+	if r.state != StatusCompleted {
+		t.Errorf("the state was %s", r.state)
+	}
+
+	s.DateStarted = submitted.Add(30 * time.Second)
+	r, err = s.exportRecord(timeline, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.started.Equal(s.DateStarted) {
+		t.Errorf("DateStarted didn't take precedence over the timeline: %s", r.started)
+	}
+}
+
+func TestExportRecordFiles(t *testing.T) {
+	s := _inittests(t, false)
+	r, err := s.exportRecord(nil, &ExportConfig{IRODSHost: "data.cyverse.org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.inputs) == 0 {
+		t.Fatal("the record has no inputs")
+	}
+	expected := "irods://data.cyverse.org/iplant/home/wregglej/Acer-tree.txt"
+	if r.inputs[0].uri != expected {
+		t.Errorf("the first input's URI was %s instead of %s", r.inputs[0].uri, expected)
+	}
+	if len(r.outputs) != len(s.Outputs()) {
+		t.Errorf("the record has %d outputs instead of %d", len(r.outputs), len(s.Outputs()))
+	}
+	if len(r.images) != 1 || len(r.tools) != len(s.Steps) {
+		t.Errorf("the record has %d images and %d tools", len(r.images), len(r.tools))
+	}
+	if !r.submitted.IsZero() || r.state != "" {
+		t.Error("the record has times or a state without a timeline")
+	}
+}
+
+func TestExportParamIDs(t *testing.T) {
+	s := _inittests(t, false)
+	s.Steps[0].Config.Params = []StepParam{
+		{ID: "p1", Name: "-i", Value: "a", Order: 0},
+		{ID: "p1", Name: "-i", Value: "b", Order: 1},
+		{ID: "p1-2", Name: "-x", Value: "c", Order: 2},
+		{Name: "-v", Order: 3},
+	}
+	r, err := s.exportRecord(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, p := range r.params {
+		ids = append(ids, p.id)
+	}
+	expected := []string{"step-1-param-p1", "step-1-param-p1-2", "step-1-param-p1-2-2", "step-1-param-4"}
+	if !reflect.DeepEqual(ids[:len(expected)], expected) {
+		t.Errorf("the parameter IDs were %v instead of %v", ids, expected)
+	}
+
+	crate, err := s.ROCrate(nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(map[any]bool)
+	for _, e := range crate.Graph {
+		if seen[e["@id"]] {
+			t.Errorf("the crate contains more than one entity with the ID %v", e["@id"])
+		}
+		seen[e["@id"]] = true
+	}
+}
@@ -0,0 +1,155 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+const (
+	// DefaultRegistry is the registry used for image names that don't include
+	// a registry host.
+	DefaultRegistry = "docker.io"
+
+	// DefaultTag is the tag used for image references that have neither a tag
+	// nor a digest.
+	DefaultTag = "latest"
+
+	// officialRepoPrefix is prepended to single-component repositories in the
+	// default registry, e.g. alpine becomes library/alpine.
+	officialRepoPrefix = "library/"
+)
+
+// The regular expressions below follow the reference grammar used by the
+// docker distribution project.
+var (
+	domainComponent = `(?:[a-zA-Z0-9]|[a-zA-Z0-9][a-zA-Z0-9-]*[a-zA-Z0-9])`
+	domainRegexp    = regexp.MustCompile(`^(?:` + domainComponent + `(?:\.` + domainComponent + `)*|\[[a-fA-F0-9:]+\])(?::[0-9]+)?$`)
+	pathComponent   = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|[-]*)[a-z0-9]+)*$`)
+	tagRegexp       = regexp.MustCompile(`^[\w][\w.-]{0,127}$`)
+	digestRegexp    = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9]*(?:[-_+.][A-Za-z][A-Za-z0-9]*)*:[0-9a-fA-F]{32,}$`)
+)
+
+// ImageReference is a normalized container image reference.
+type ImageReference struct {
+	Registry   string // The registry host, including the port if there is one.
+	Repository string // The repository path within the registry.
+	Tag        string
+	Digest     string // The content digest, e.g. sha256:<hex>.
+}
+
+// ParseImageReference parses an image reference such as
+// "harbor.cyverse.org/de/vice-proxy:latest" or
+// "alpine@sha256:<hex>" into its components. Names without a registry host are
+// placed in the docker.io registry, and single-component names in that registry
+// get the library/ prefix. References without a tag or digest get the latest
+// tag.
+func ParseImageReference(ref string) (ImageReference, error) {
+	var r ImageReference
+	name := strings.TrimSpace(ref)
+	if name == "" {
+		return r, fmt.Errorf("image reference is empty")
+	}
+
+	if i := strings.Index(name, "@"); i >= 0 {
+		r.Digest = name[i+1:]
+		name = name[:i]
+		if !digestRegexp.MatchString(r.Digest) {
+			return ImageReference{}, fmt.Errorf("invalid digest %q in image reference %q", r.Digest, ref)
+		}
+	}
+
+	// A colon after the last slash separates the tag. Colons before the last
+	// slash are part of the registry host's port.
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		r.Tag = name[i+1:]
+		name = name[:i]
+		if !tagRegexp.MatchString(r.Tag) {
+			return ImageReference{}, fmt.Errorf("invalid tag %q in image reference %q", r.Tag, ref)
+		}
+	}
+
+	// The first component is a registry host if it looks like one: it contains
+	// a dot or a port, or it's localhost.
+	if i := strings.Index(name, "/"); i >= 0 {
+		first := name[:i]
+		if strings.ContainsAny(first, ".:") || first == "localhost" || strings.ToLower(first) != first {
+			if !domainRegexp.MatchString(first) {
+				return ImageReference{}, fmt.Errorf("invalid registry %q in image reference %q", first, ref)
+			}
+			r.Registry = first
+			name = name[i+1:]
+		}
+	}
+	if r.Registry == "" || r.Registry == "index.docker.io" || r.Registry == "registry-1.docker.io" {
+		r.Registry = DefaultRegistry
+	}
+
+	if name == "" {
+		return ImageReference{}, fmt.Errorf("image reference %q has no repository", ref)
+	}
+	for _, component := range strings.Split(name, "/") {
+		if !pathComponent.MatchString(component) {
+			return ImageReference{}, fmt.Errorf("invalid repository %q in image reference %q", name, ref)
+		}
+	}
+	if r.Registry == DefaultRegistry && !strings.Contains(name, "/") {
+		name = officialRepoPrefix + name
+	}
+	r.Repository = name
+
+	if r.Tag == "" && r.Digest == "" {
+		r.Tag = DefaultTag
+	}
+	return r, nil
+}
+
+// Name returns the registry and repository joined together, without the tag or
+// digest.
+func (r ImageReference) Name() string {
+	return fmt.Sprintf("%s/%s", r.Registry, r.Repository)
+}
+
+// FamiliarName returns the name in the short form that docker displays, with
+// the docker.io registry and library/ prefix removed.
+func (r ImageReference) FamiliarName() string {
+	if r.Registry != DefaultRegistry {
+		return r.Name()
+	}
+	return strings.TrimPrefix(r.Repository, officialRepoPrefix)
+}
+
+// String returns the canonical form of the reference, which always includes
+// the registry and includes the tag and digest when they're set.
+func (r ImageReference) String() string {
+	s := r.Name()
+	if r.Tag != "" {
+		s = fmt.Sprintf("%s:%s", s, r.Tag)
+	}
+	if r.Digest != "" {
+		s = fmt.Sprintf("%s@%s", s, r.Digest)
+	}
+	return s
+}
+
+// IsPinned returns true if the reference includes a digest.
+func (r ImageReference) IsPinned() bool {
+	return r.Digest != ""
+}
+
+// Reference parses the image's Name and Tag into a normalized ImageReference.
+// The Name field may already contain a tag or digest; a non-empty Tag field
+// takes precedence over a tag in the name.
+func (i *ContainerImage) Reference() (ImageReference, error) {
+	r, err := ParseImageReference(i.Name)
+	if err != nil {
+		return r, err
+	}
+	if i.Tag != "" {
+		if !tagRegexp.MatchString(i.Tag) {
+			return ImageReference{}, fmt.Errorf("invalid tag %q for image %q", i.Tag, i.Name)
+		}
+		r.Tag = i.Tag
+	}
+	return r, nil
+}
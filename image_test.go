@@ -0,0 +1,116 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+const testDigest = "sha256:4bcff63911fcb4448bd4fdacec207030997caf25e9bea4045fa6c8c44de311d1"
+
+func TestParseImageReference(t *testing.T) {
+	refs := map[string]ImageReference{
+		"alpine": {
+			Registry: "docker.io", Repository: "library/alpine", Tag: "latest",
+		},
+		"discoenv/backwards-compat:latest": {
+			Registry: "docker.io", Repository: "discoenv/backwards-compat", Tag: "latest",
+		},
+		"gims.iplantcollaborative.org:5000/backwards-compat": {
+			Registry: "gims.iplantcollaborative.org:5000", Repository: "backwards-compat", Tag: "latest",
+		},
+		"harbor.cyverse.org/legacy/backwards-compat:1.0": {
+			Registry: "harbor.cyverse.org", Repository: "legacy/backwards-compat", Tag: "1.0",
+		},
+		"localhost/foo": {
+			Registry: "localhost", Repository: "foo", Tag: "latest",
+		},
+		"index.docker.io/discoenv/porklock": {
+			Registry: "docker.io", Repository: "discoenv/porklock", Tag: "latest",
+		},
+		"alpine@" + testDigest: {
+			Registry: "docker.io", Repository: "library/alpine", Digest: testDigest,
+		},
+		"localhost:5000/a/b:dev@" + testDigest: {
+			Registry: "localhost:5000", Repository: "a/b", Tag: "dev", Digest: testDigest,
+		},
+	}
+	for ref, expected := range refs {
+		actual, err := ParseImageReference(ref)
+		if err != nil {
+			t.Errorf("ParseImageReference(%q) returned an error: %s", ref, err)
+			continue
+		}
+		if !reflect.DeepEqual(actual, expected) {
+			t.Errorf("ParseImageReference(%q) returned %#v instead of %#v", ref, actual, expected)
+		}
+	}
+}
+
+func TestParseImageReferenceErrors(t *testing.T) {
+	refs := []string{
+		"",
+		"UPPER",
+		"foo:bad tag",
+		"foo@sha256:short",
+		"host:5000/",
+		"foo//bar",
+	}
+	for _, ref := range refs {
+		if _, err := ParseImageReference(ref); err == nil {
+			t.Errorf("ParseImageReference(%q) did not return an error", ref)
+		}
+	}
+}
+
+func TestImageReferenceString(t *testing.T) {
+	refs := map[string]string{
+		"alpine":                      "docker.io/library/alpine:latest",
+		"discoenv/porklock:dev":       "docker.io/discoenv/porklock:dev",
+		"alpine@" + testDigest:        "docker.io/library/alpine@" + testDigest,
+		"a.io:5000/b:c@" + testDigest: "a.io:5000/b:c@" + testDigest,
+	}
+	for ref, expected := range refs {
+		r, err := ParseImageReference(ref)
+		if err != nil {
+			t.Errorf("ParseImageReference(%q) returned an error: %s", ref, err)
+			continue
+		}
+		if actual := r.String(); actual != expected {
+			t.Errorf("String() returned '%s' instead of '%s'", actual, expected)
+		}
+	}
+}
+
+func TestImageReferenceFamiliarName(t *testing.T) {
+	r, _ := ParseImageReference("alpine")
+	if actual := r.FamiliarName(); actual != "alpine" {
+		t.Errorf("FamiliarName() returned '%s' instead of 'alpine'", actual)
+	}
+	r, _ = ParseImageReference("gims.iplantcollaborative.org:5000/backwards-compat")
+	expected := "gims.iplantcollaborative.org:5000/backwards-compat"
+	if actual := r.FamiliarName(); actual != expected {
+		t.Errorf("FamiliarName() returned '%s' instead of '%s'", actual, expected)
+	}
+}
+
+func TestContainerImageReference(t *testing.T) {
+	s := inittests(t)
+	actual, err := s.Steps[0].Component.Container.Image.Reference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "gims.iplantcollaborative.org:5000/backwards-compat:latest"
+	if actual.String() != expected {
+		t.Errorf("Reference() returned '%s' instead of '%s'", actual, expected)
+	}
+
+	image := ContainerImage{Name: "discoenv/porklock@" + testDigest, Tag: "dev"}
+	actual, err = image.Reference()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = "docker.io/discoenv/porklock:dev@" + testDigest
+	if actual.String() != expected {
+		t.Errorf("Reference() returned '%s' instead of '%s'", actual, expected)
+	}
+}
@@ -16,11 +16,13 @@ type StepInput struct {
 	Retain       bool   `json:"retain"`
 	Type         string `json:"type"`
 	Value        string `json:"value"`
+
+	layout *Layout // set by Job.SetLayout().
 }
 
 // IRODSPath returns a string containing the iRODS path to an input file.
 func (i *StepInput) IRODSPath() string {
-	if i.Multiplicity == "collection" {
+	if i.ParsedMultiplicity().IsCollection() {
 		if !strings.HasSuffix(i.Value, "/") {
 			return fmt.Sprintf("%s/", i.Value)
 		}
@@ -37,25 +39,25 @@ func (i *StepInput) Identifier(suffix string) string {
 // Stdout returns a string containing the path to the input job's stdout file.
 // It should be a relative path in the format "logs/logs-stdout-<i.Identifier(suffix)>"
 func (i *StepInput) Stdout(suffix string) string {
-	return path.Join("logs", fmt.Sprintf("logs-stdout-%s", i.Identifier(suffix)))
+	return i.layout.LogFile(fmt.Sprintf("logs-stdout-%s", i.Identifier(suffix)))
 }
 
 // Stderr returns a string containing the path to the input job's stderr file.
 // It should be a relative path in the format "logs/logs-stderr-<i.Identifier(suffix)>"
 func (i *StepInput) Stderr(suffix string) string {
-	return path.Join("logs", fmt.Sprintf("logs-stderr-%s", i.Identifier(suffix)))
+	return i.layout.LogFile(fmt.Sprintf("logs-stderr-%s", i.Identifier(suffix)))
 }
 
 // LogPath returns the path to the Condor log file for the input job. The returned
 // path will be in the format "<parent>/logs/logs-condor-<i.Identifier(suffix)>"
 func (i *StepInput) LogPath(parent, suffix string) string {
-	return path.Join(parent, "logs", fmt.Sprintf("logs-condor-%s", i.Identifier(suffix)))
+	return path.Join(parent, i.layout.LogFile(fmt.Sprintf("logs-condor-%s", i.Identifier(suffix))))
 }
 
 // Source returns the path to the local filename of the input file.
 func (i *StepInput) Source() string {
 	value := path.Base(i.Value)
-	if i.Multiplicity == "collection" {
+	if i.ParsedMultiplicity().IsCollection() {
 		if !strings.HasSuffix(value, "/") {
 			return fmt.Sprintf("%s/", value)
 		}
@@ -69,7 +71,7 @@ func (j *Job) InputSourceListArguments(sourceListPath string) []string {
 		"get",
 		"--user", j.Submitter,
 		"--source-list", sourceListPath,
-		"--config", "/configs/irods-config",
+		"--config", j.layout.PorklockConfigPath(),
 	}
 
 	args = append(args, MetadataArgs(j.FileMetadata).FileMetadataArguments()...)
@@ -83,7 +85,7 @@ func (i *StepInput) Arguments(username string, metadata []FileMetadata) []string
 		"get",
 		"--user", username,
 		"--source", i.IRODSPath(),
-		"--config", "/configs/irods-config",
+		"--config", i.layout.PorklockConfigPath(),
 	}
 
 	args = append(args, MetadataArgs(metadata).FileMetadataArguments()...)
@@ -99,14 +101,17 @@ type StepOutput struct {
 	QualID       string `json:"qual-id"`
 	Retain       bool   `json:"retain"`
 	Type         string `json:"type"`
+
+	layout *Layout // set by Job.SetLayout().
 }
 
-// Source returns the path to the local filename for the output file.
+// Source returns the path to the local filename for the output file. Relative
+// collections are placed inside the working directory of the job's layout.
 func (o *StepOutput) Source() string {
 	value := o.Name
-	if o.Multiplicity == "collection" {
+	if o.ParsedMultiplicity().IsCollection() {
 		if !path.IsAbs(value) {
-			value = fmt.Sprintf("/de-app-work/%s", value)
+			value = fmt.Sprintf("%s/%s", o.layout.WorkingDirectory(), value)
 		}
 		if !strings.HasSuffix(value, "/") {
 			value = fmt.Sprintf("%s/", value)
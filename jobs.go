@@ -6,7 +6,7 @@ package model
 import (
 	"crypto/sha256"
 	"encoding/hex"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"path"
 	"regexp"
@@ -14,7 +14,6 @@ import (
 	"time"
 
 	"github.com/cyverse-de/model/v8/submitfile"
-	"github.com/spf13/viper"
 )
 
 var (
@@ -116,74 +115,43 @@ type Job struct {
 	WikiURL            string         `json:"wiki_url"`
 	ConfigFile         string         `json:"config_file"` //path to the job configuration file (not from upstream)
 	MountDataStore     bool           `json:"mount_data_store"`
+
+	layout *Layout // where things are placed at run time; see SetLayout().
 }
 
 // Analysis is the same type as Job. Our terminology has changed over time,
 // and the code is out of date.
 type Analysis = Job
 
-// New returns a pointer to a newly instantiated Job with NowDate set.
-// Accesses the following configuration settings:
-//   - condor.log_path
-//   - condor.filter_files
-//   - irods.base
-func New(cfg *viper.Viper) *Job {
-	n := time.Now().Format(nowfmt)
-	lp := cfg.GetString("condor.log_path")
-	var paths []string
-	filterFiles := cfg.GetString("condor.filter_files")
-	paths = append(paths, strings.Split(filterFiles, ",")...)
-	irodsBase := cfg.GetString("irods.base")
-	return &Job{
-		NowDate:        n,
-		SubmissionDate: n,
-		ArchiveLogs:    true,
-		CondorLogPath:  lp,
-		FilterFiles:    paths,
-		IRODSBase:      irodsBase,
-	}
-}
-
-// NewFromData creates a new submission and populates it by parsing the passed
-// in []byte as JSON.
-func NewFromData(cfg *viper.Viper, data []byte) (*Job, error) {
-	var err error
-	s := New(cfg)
-	err = json.Unmarshal(data, s)
-	if err != nil {
-		return nil, err
-	}
-	s.Sanitize()
-	s.AddRequiredMetadata()
-	return s, err
-}
-
 // ModelConfig contains the fields that need to be set outside of a analysis
 // definition
+//
+// Deprecated: use NewJob with WithLogPath, WithFilterFiles, WithIRODSBase and
+// WithBackwardsCompatPatterns instead.
 type AnalysisConfig struct {
 	LogPath     string
 	FilterFiles []string
 	IRODSBase   string
+
+	// Replaces the backwards compatible image patterns if it's not empty.
+	BackwardsCompatPatterns []string
 }
 
-// Creates a new Analysis/Job from the data and AnalysisConfig.
-func NewAnalysis(cfg *AnalysisConfig, data []byte) (*Analysis, error) {
-	var err error
-	n := time.Now().Format(nowfmt)
-	analysis := &Analysis{
-		NowDate:        n,
-		SubmissionDate: n,
-		ArchiveLogs:    true,
-		CondorLogPath:  cfg.LogPath,
-		FilterFiles:    cfg.FilterFiles,
-		IRODSBase:      cfg.IRODSBase,
+// Creates a new Analysis/Job from the data and AnalysisConfig. The options are
+// applied after the ones built from the AnalysisConfig, so they take
+// precedence.
+//
+// Deprecated: use NewJob instead.
+func NewAnalysis(cfg *AnalysisConfig, data []byte, opts ...Option) (*Analysis, error) {
+	cfgOpts := []Option{
+		WithLogPath(cfg.LogPath),
+		WithFilterFiles(cfg.FilterFiles...),
+		WithIRODSBase(cfg.IRODSBase),
 	}
-	if err = json.Unmarshal(data, analysis); err != nil {
-		return nil, err
+	if len(cfg.BackwardsCompatPatterns) > 0 {
+		cfgOpts = append(cfgOpts, WithBackwardsCompatPatterns(cfg.BackwardsCompatPatterns...))
 	}
-	analysis.Sanitize()
-	analysis.AddRequiredMetadata()
-	return analysis, nil
+	return NewJob(data, append(cfgOpts, opts...)...)
 }
 
 // sanitize replaces @ and spaces with _, making a string safe to use as a
@@ -227,7 +195,9 @@ func (job *Job) Sanitize() {
 // DirectoryName creates a directory name for an analysis. Used when the submission
 // doesn't specify an output directory.  Some types of jobs, for example
 // Foundational API jobs, include a timestamp in the job name, so a timestamp
-// will not be appended to the directory name in those cases.
+// will not be appended to the directory name in those cases. The timestamp is
+// NowDate, so pass WithClock() and WithUTC() to the constructor to get the same
+// directory name every time.
 func (job *Job) DirectoryName() string {
 	if validName.MatchString(job.Name) {
 		return job.Name
@@ -258,22 +228,14 @@ func (job *Job) CondorLogDirectory() string {
 
 // IRODSConfig returns the path to iRODS config inside the working directory.
 func (job *Job) IRODSConfig() string {
-	return path.Join("logs", "irods-config")
+	return job.layout.IRODSConfigPath()
 }
 
 // OutputDirectory returns the path to the output directory in iRODS. It's
 // computed, which is why it isn't in the struct. Use this instead of directly
 // accessing the OutputDir field.
 func (job *Job) OutputDirectory() string {
-	if job.OutputDir == "" {
-		return path.Join(job.IRODSBase, job.Submitter, "analyses", job.DirectoryName())
-	} else if job.OutputDir != "" && job.CreateOutputSubdir {
-		return path.Join(job.OutputDir, job.DirectoryName())
-	} else if job.OutputDir != "" && !job.CreateOutputSubdir {
-		return strings.TrimSuffix(job.OutputDir, "/")
-	}
-	//probably won't ever reach this, but just in case...
-	return path.Join(job.IRODSBase, job.Submitter, "analyses", job.DirectoryName())
+	return job.outputDirectoryNamed(job.DirectoryName())
 }
 
 // DataContainers returns a list of VolumesFrom that describe the data
@@ -331,7 +293,7 @@ func (job *Job) ExcludeArguments() []string {
 	}
 	paths = append(paths, job.FilterFiles...)
 	if !job.ArchiveLogs {
-		paths = append(paths, "logs")
+		paths = append(paths, job.layout.LogsDirectory())
 	}
 
 	return paths
@@ -339,38 +301,11 @@ func (job *Job) ExcludeArguments() []string {
 
 // AddRequiredMetadata adds any required AVUs that are required but are missing
 // from Job.FileMetadata. This should be called after both of the New*()
-// functions and after the Job has been initialized from JSON.
+// functions and after the Job has been initialized from JSON. It uses
+// DefaultRequiredAVUs; the constructors use the rules from
+// WithRequiredMetadata() instead if it's passed to them.
 func (job *Job) AddRequiredMetadata() {
-	foundAnalysis := false
-	foundExecution := false
-	for _, md := range job.FileMetadata {
-		if md.Attribute == "ipc-analysis-id" {
-			foundAnalysis = true
-		}
-		if md.Attribute == "ipc-execution-id" {
-			foundExecution = true
-		}
-	}
-	if !foundAnalysis {
-		job.FileMetadata = append(
-			job.FileMetadata,
-			FileMetadata{
-				Attribute: "ipc-analysis-id",
-				Value:     job.AppID,
-				Unit:      "UUID",
-			},
-		)
-	}
-	if !foundExecution {
-		job.FileMetadata = append(
-			job.FileMetadata,
-			FileMetadata{
-				Attribute: "ipc-execution-id",
-				Value:     job.InvocationID,
-				Unit:      "UUID",
-			},
-		)
-	}
+	job.AddRequiredMetadataWith(DefaultRequiredAVUs)
 }
 
 // FinalOutputArguments returns a string containing the arguments passed to
@@ -382,7 +317,7 @@ func (job *Job) FinalOutputArguments(excludeFilePath string) []string {
 		"put",
 		"--user", job.Submitter,
 		"--destination", dest,
-		"--config", "/configs/irods-config",
+		"--config", job.layout.PorklockConfigPath(),
 	}
 	retval = append(retval, MetadataArgs(job.FileMetadata).FileMetadataArguments()...)
 	if excludeFilePath != "" {
@@ -484,8 +419,12 @@ type FileMetadata struct {
 }
 
 // Argument returns a string containing the command-line settings for the
-// file transfer tool.
+// file transfer tool. It returns nil if the AVU doesn't pass Validate(), since
+// porklock would misread it; for example, it splits the setting on commas.
 func (m *FileMetadata) Argument() []string {
+	if m.Validate() != nil {
+		return nil
+	}
 	return []string{"-m", fmt.Sprintf("%s,%s,%s", m.Attribute, m.Value, m.Unit)}
 }
 
@@ -493,11 +432,25 @@ func (m *FileMetadata) Argument() []string {
 type MetadataArgs []FileMetadata
 
 // FileMetadataArguments returns a string containing the command-line arguments
-// for porklock that sets all of the metadata triples.
+// for porklock that sets all of the metadata triples. AVUs that don't pass
+// FileMetadata.Validate() are left out; see CheckedArguments().
 func (m MetadataArgs) FileMetadataArguments() []string {
+	retval, _ := m.CheckedArguments()
+	return retval
+}
+
+// CheckedArguments returns the same arguments as FileMetadataArguments(), along
+// with an error describing the AVUs that were left out because they don't pass
+// FileMetadata.Validate().
+func (m MetadataArgs) CheckedArguments() ([]string, error) {
 	retval := []string{}
-	for _, fm := range m {
+	var errs []error
+	for i, fm := range m {
+		if err := fm.Validate(); err != nil {
+			errs = append(errs, &AVUError{Index: i, AVU: fm, Problem: err.Error()})
+			continue
+		}
 		retval = append(retval, fm.Argument()...)
 	}
-	return retval
+	return retval, errors.Join(errs...)
 }
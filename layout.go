@@ -0,0 +1,124 @@
+package model
+
+import "path"
+
+// Layout describes where things are placed at run time: inside the container,
+// and inside the working directory on the execution node. Execution platforms
+// that arrange things differently can set the Layout on the Job. Empty fields
+// use the values from DefaultLayout.
+type Layout struct {
+	// The container's working directory when the step doesn't specify one.
+	WorkDir string `json:"work_dir,omitempty"`
+
+	// The directory in the container that the porklock configuration is
+	// mounted into.
+	ConfigDir string `json:"config_dir,omitempty"`
+
+	// The directory that logs are written to, relative to the working
+	// directory.
+	LogsDir string `json:"logs_dir,omitempty"`
+
+	// The file name of the porklock configuration, both in ConfigDir and in
+	// LogsDir.
+	PorklockConfig string `json:"porklock_config,omitempty"`
+}
+
+// DefaultLayout is the layout used when a Job doesn't have one.
+var DefaultLayout = Layout{
+	WorkDir:        DefaultWorkingDirectory,
+	ConfigDir:      "/configs",
+	LogsDir:        "logs",
+	PorklockConfig: "irods-config",
+}
+
+// resolved returns a copy of the layout with the empty fields filled in from
+// DefaultLayout. A nil layout resolves to DefaultLayout.
+func (l *Layout) resolved() Layout {
+	if l == nil {
+		return DefaultLayout
+	}
+	r := *l
+	if r.WorkDir == "" {
+		r.WorkDir = DefaultLayout.WorkDir
+	}
+	if r.ConfigDir == "" {
+		r.ConfigDir = DefaultLayout.ConfigDir
+	}
+	if r.LogsDir == "" {
+		r.LogsDir = DefaultLayout.LogsDir
+	}
+	if r.PorklockConfig == "" {
+		r.PorklockConfig = DefaultLayout.PorklockConfig
+	}
+	return r
+}
+
+// WorkingDirectory returns the default working directory in the container.
+func (l *Layout) WorkingDirectory() string {
+	return l.resolved().WorkDir
+}
+
+// ConfigDirectory returns the directory in the container that the porklock
+// configuration is mounted into.
+func (l *Layout) ConfigDirectory() string {
+	return l.resolved().ConfigDir
+}
+
+// LogsDirectory returns the logs directory, relative to the working directory.
+func (l *Layout) LogsDirectory() string {
+	return l.resolved().LogsDir
+}
+
+// LogFile returns the path to a file in the logs directory, relative to the
+// working directory.
+func (l *Layout) LogFile(name string) string {
+	return path.Join(l.LogsDirectory(), name)
+}
+
+// PorklockConfigPath returns the path to the porklock configuration inside the
+// container.
+func (l *Layout) PorklockConfigPath() string {
+	r := l.resolved()
+	return path.Join(r.ConfigDir, r.PorklockConfig)
+}
+
+// IRODSConfigPath returns the path to the porklock configuration inside the
+// working directory.
+func (l *Layout) IRODSConfigPath() string {
+	r := l.resolved()
+	return path.Join(r.LogsDir, r.PorklockConfig)
+}
+
+// Layout returns the layout used for the job's run-time paths. It returns nil
+// if the job uses DefaultLayout; the Layout methods handle a nil layout.
+func (job *Job) Layout() *Layout {
+	return job.layout
+}
+
+// SetLayout sets the layout used for the job's run-time paths, including the
+// paths returned by the methods of its steps and of their containers, inputs
+// and outputs. The layout is server configuration rather than part of the job
+// submission, so it isn't kept when the job is encoded as JSON; clones keep it.
+// Steps added afterwards use DefaultLayout until SetLayout is called again.
+func (job *Job) SetLayout(l Layout) {
+	job.layout = &l
+	for i := range job.Steps {
+		job.Steps[i].setLayout(job.layout)
+	}
+}
+
+// setLayout sets the layout of the step and everything in it.
+func (s *Step) setLayout(l *Layout) {
+	s.layout = l
+	s.Component.Container.layout = l
+	for _, inputs := range [][]StepInput{s.Input, s.Config.Inputs} {
+		for i := range inputs {
+			inputs[i].layout = l
+		}
+	}
+	for _, outputs := range [][]StepOutput{s.Output, s.Config.Outputs} {
+		for i := range outputs {
+			outputs[i].layout = l
+		}
+	}
+}
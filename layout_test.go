@@ -0,0 +1,158 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+var testLayout = Layout{
+	WorkDir:        "/work-alt",
+	ConfigDir:      "/etc/porklock",
+	LogsDir:        "run-logs",
+	PorklockConfig: "porklock.json",
+}
+
+func TestLayoutDefaults(t *testing.T) {
+	var l *Layout
+	if l.WorkingDirectory() != "/de-app-work" {
+		t.Errorf("WorkingDirectory() returned '%s'", l.WorkingDirectory())
+	}
+	if l.PorklockConfigPath() != "/configs/irods-config" {
+		t.Errorf("PorklockConfigPath() returned '%s'", l.PorklockConfigPath())
+	}
+	if l.IRODSConfigPath() != "logs/irods-config" {
+		t.Errorf("IRODSConfigPath() returned '%s'", l.IRODSConfigPath())
+	}
+
+	partial := &Layout{LogsDir: "other-logs"}
+	if partial.IRODSConfigPath() != "other-logs/irods-config" {
+		t.Errorf("IRODSConfigPath() returned '%s'", partial.IRODSConfigPath())
+	}
+	if partial.ConfigDirectory() != "/configs" {
+		t.Errorf("ConfigDirectory() returned '%s'", partial.ConfigDirectory())
+	}
+}
+
+func TestJobLayout(t *testing.T) {
+	s := _inittests(t, false)
+	s.SetLayout(testLayout)
+	s.ArchiveLogs = false
+
+	if s.IRODSConfig() != "run-logs/porklock.json" {
+		t.Errorf("IRODSConfig() returned '%s'", s.IRODSConfig())
+	}
+
+	args := s.FinalOutputArguments("")
+	if args[5] != "--config" || args[6] != "/etc/porklock/porklock.json" {
+		t.Errorf("FinalOutputArguments() returned %#v", args)
+	}
+
+	args = s.InputSourceListArguments("/path/to/list")
+	if args[5] != "--config" || args[6] != "/etc/porklock/porklock.json" {
+		t.Errorf("InputSourceListArguments() returned %#v", args)
+	}
+
+	excludes := s.ExcludeArguments()
+	if excludes[len(excludes)-1] != "run-logs" {
+		t.Errorf("ExcludeArguments() returned %#v", excludes)
+	}
+	if !s.ExcludeList().Matches("/work-alt/run-logs/condor-stdout-0") {
+		t.Error("ExcludeList() doesn't exclude the logs directory")
+	}
+
+	step := s.Clone().Steps[0]
+	step.StdoutPath = ""
+	step.LogFile = ""
+	if step.Stdout("0") != "run-logs/condor-stdout-0" {
+		t.Errorf("Stdout() returned '%s'", step.Stdout("0"))
+	}
+	if step.LogPath("/parent", "0") != "/parent/run-logs/condor-log-0" {
+		t.Errorf("LogPath() returned '%s'", step.LogPath("/parent", "0"))
+	}
+	step.Component.Container.WorkingDir = ""
+	if step.Component.Container.WorkingDirectory() != "/work-alt" {
+		t.Errorf("WorkingDirectory() returned '%s'", step.Component.Container.WorkingDirectory())
+	}
+
+	input := step.Config.Inputs[0]
+	if input.Stdout("0") != "run-logs/logs-stdout-input-0" {
+		t.Errorf("Stdout() returned '%s'", input.Stdout("0"))
+	}
+	args = input.Arguments(s.Submitter, nil)
+	if args[5] != "--config" || args[6] != "/etc/porklock/porklock.json" {
+		t.Errorf("Arguments() returned %#v", args)
+	}
+
+	output := StepOutput{Name: "results", Multiplicity: "collection", layout: s.Layout()}
+	if output.Source() != "/work-alt/results/" {
+		t.Errorf("Source() returned '%s'", output.Source())
+	}
+
+	apptainer, err := step.ApptainerArguments(ApptainerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for i := range apptainer[:len(apptainer)-1] {
+		found = found || (apptainer[i] == "--pwd" && apptainer[i+1] == "/work-alt")
+	}
+	if !found {
+		t.Errorf("ApptainerArguments() returned %#v", apptainer)
+	}
+}
+
+func TestJobLayoutMountConflicts(t *testing.T) {
+	s := _inittests(t, false)
+	s.SetLayout(Layout{ConfigDir: "/container/path1"})
+	found := false
+	for _, c := range s.MountConflicts() {
+		if c.Source == "the configuration directory" || c.OtherSource == "the configuration directory" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("MountConflicts() didn't report a volume mounted on the configuration directory")
+	}
+}
+
+func TestNewJobWithLayout(t *testing.T) {
+	data := []byte(`{"name": "test", "steps": [{"component": {"container": {}}}]}`)
+	j, err := NewJob(data, WithLayout(testLayout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Layout() == nil || *j.Layout() != testLayout {
+		t.Fatalf("NewJob() set the layout to %#v", j.Layout())
+	}
+	if j.Steps[0].Component.Container.WorkingDirectory() != "/work-alt" {
+		t.Errorf("WorkingDirectory() returned '%s'", j.Steps[0].Component.Container.WorkingDirectory())
+	}
+
+	// The submission can't override the server's layout.
+	j, err = NewJob([]byte(`{"name": "test", "layout": {"config_dir": "/home/user/.irods"}}`), WithLayout(testLayout))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Layout().ConfigDirectory() != "/etc/porklock" {
+		t.Errorf("the submission's layout was used: %#v", j.Layout())
+	}
+	j, err = NewJob([]byte(`{"name": "test", "layout": {"config_dir": "/home/user/.irods"}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.Layout() != nil {
+		t.Errorf("the submission's layout was used: %#v", j.Layout())
+	}
+
+	data, err = json.Marshal(j)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]interface{}
+	if err = json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := m["layout"]; ok {
+		t.Error("a job was serialized with its layout")
+	}
+}
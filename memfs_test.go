@@ -0,0 +1,68 @@
+package model
+
+import (
+	"path"
+	"sort"
+	"sync"
+)
+
+// memoryFS is an in-memory set of directories. Its Exists method can be used as
+// an ExistsFunc. It's safe for concurrent use.
+type memoryFS struct {
+	mu    sync.Mutex
+	paths map[string]bool
+}
+
+// newMemoryFS returns a memoryFS containing the paths.
+func newMemoryFS(paths ...string) *memoryFS {
+	fs := &memoryFS{paths: make(map[string]bool)}
+	for _, p := range paths {
+		fs.Add(p)
+	}
+	return fs
+}
+
+// add adds the path and all of its parents. The caller must hold the lock.
+func (fs *memoryFS) add(p string) {
+	for p = path.Clean(p); p != "/" && p != "."; p = path.Dir(p) {
+		fs.paths[p] = true
+	}
+}
+
+// Add adds the path and all of its parents.
+func (fs *memoryFS) Add(p string) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	fs.add(p)
+}
+
+// Create adds the path if it doesn't already exist. It returns false if the
+// path was already present, which makes it useful for reserving a directory.
+func (fs *memoryFS) Create(p string) bool {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.paths[path.Clean(p)] {
+		return false
+	}
+	fs.add(p)
+	return true
+}
+
+// Exists returns true if the path has been added. It never returns an error.
+func (fs *memoryFS) Exists(p string) (bool, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.paths[path.Clean(p)], nil
+}
+
+// Paths returns the paths in sorted order.
+func (fs *memoryFS) Paths() []string {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	var paths []string
+	for p := range fs.paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,217 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// MaxAVULength is the longest attribute, value or unit, in bytes, that iRODS
+// accepts.
+const MaxAVULength = 2700
+
+// AVUError describes a problem with one of a job's AVUs.
+type AVUError struct {
+	Index   int
+	AVU     FileMetadata
+	Problem string
+}
+
+func (e *AVUError) Error() string {
+	return fmt.Sprintf("AVU %d (%s): %s", e.Index, e.AVU.Attribute, e.Problem)
+}
+
+// Validate returns an error describing the first problem with the AVU: an empty
+// attribute or value, a field that's too long, or a field containing commas or
+// control characters such as newlines. Porklock splits the argument to -m on
+// commas, so it can't pass them through.
+func (m *FileMetadata) Validate() error {
+	if strings.TrimSpace(m.Attribute) == "" {
+		return errors.New("the attribute is empty")
+	}
+	if m.Value == "" {
+		return errors.New("the value is empty")
+	}
+	fields := []struct {
+		name  string
+		value string
+	}{
+		{"attribute", m.Attribute},
+		{"value", m.Value},
+		{"unit", m.Unit},
+	}
+	for _, f := range fields {
+		if len(f.value) > MaxAVULength {
+			return fmt.Errorf("the %s is longer than %d bytes", f.name, MaxAVULength)
+		}
+		if strings.Contains(f.value, ",") {
+			return fmt.Errorf("the %s contains a comma", f.name)
+		}
+		if strings.IndexFunc(f.value, unicode.IsControl) >= 0 {
+			return fmt.Errorf("the %s contains control characters", f.name)
+		}
+	}
+	return nil
+}
+
+// ValidateMetadata checks every AVU in the list, returning the problems joined
+// into a single error. Exact duplicates are reported as well, since iRODS
+// rejects them.
+func ValidateMetadata(metadata []FileMetadata) error {
+	var errs []error
+	seen := make(map[FileMetadata]int)
+	for i, md := range metadata {
+		if err := md.Validate(); err != nil {
+			errs = append(errs, &AVUError{Index: i, AVU: md, Problem: err.Error()})
+		}
+		if first, ok := seen[md]; ok {
+			errs = append(errs, &AVUError{Index: i, AVU: md, Problem: fmt.Sprintf("duplicates AVU %d", first)})
+		} else {
+			seen[md] = i
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateMetadata checks the job's AVUs. See ValidateMetadata().
+func (job *Job) ValidateMetadata() error {
+	return ValidateMetadata(job.FileMetadata)
+}
+
+// DedupeMetadata returns the AVUs with exact duplicates removed, keeping the
+// first occurrence of each.
+func DedupeMetadata(metadata []FileMetadata) []FileMetadata {
+	var deduped []FileMetadata
+	seen := make(map[FileMetadata]bool)
+	for _, md := range metadata {
+		if seen[md] {
+			continue
+		}
+		seen[md] = true
+		deduped = append(deduped, md)
+	}
+	return deduped
+}
+
+// MergePolicy determines what MergeMetadata does with AVUs whose attribute is
+// already present.
+type MergePolicy string
+
+const (
+	// MergeKeepFirst drops new AVUs whose attribute is already present.
+	MergeKeepFirst MergePolicy = "keep-first"
+
+	// MergeOverride replaces the existing AVUs with the new ones that have the
+	// same attribute, at the position of the first existing one.
+	MergeOverride MergePolicy = "override"
+
+	// MergeAppend keeps both, since iRODS allows several values for an
+	// attribute. Exact duplicates are still dropped.
+	MergeAppend MergePolicy = "append"
+)
+
+// MergeMetadata merges the additions into the base list according to the
+// policy, returning a new list. Neither argument is modified.
+func MergeMetadata(base, additions []FileMetadata, policy MergePolicy) ([]FileMetadata, error) {
+	switch policy {
+	case MergeKeepFirst:
+		merged := append([]FileMetadata{}, base...)
+		present := make(map[string]bool)
+		for _, md := range base {
+			present[md.Attribute] = true
+		}
+		for _, md := range additions {
+			if !present[md.Attribute] {
+				merged = append(merged, md)
+			}
+		}
+		return DedupeMetadata(merged), nil
+
+	case MergeOverride:
+		replacements := make(map[string][]FileMetadata)
+		var order []string
+		for _, md := range additions {
+			if _, ok := replacements[md.Attribute]; !ok {
+				order = append(order, md.Attribute)
+			}
+			replacements[md.Attribute] = append(replacements[md.Attribute], md)
+		}
+		var merged []FileMetadata
+		replaced := make(map[string]bool)
+		for _, md := range base {
+			r, ok := replacements[md.Attribute]
+			if !ok {
+				merged = append(merged, md)
+				continue
+			}
+			if !replaced[md.Attribute] {
+				merged = append(merged, r...)
+				replaced[md.Attribute] = true
+			}
+		}
+		for _, attr := range order {
+			if !replaced[attr] {
+				merged = append(merged, replacements[attr]...)
+			}
+		}
+		return DedupeMetadata(merged), nil
+
+	case MergeAppend:
+		return DedupeMetadata(append(append([]FileMetadata{}, base...), additions...)), nil
+	}
+	return nil, fmt.Errorf("unsupported merge policy %q", policy)
+}
+
+// MergeMetadata merges the AVUs into the job's metadata according to the
+// policy.
+func (job *Job) MergeMetadata(additions []FileMetadata, policy MergePolicy) error {
+	merged, err := MergeMetadata(job.FileMetadata, additions, policy)
+	if err != nil {
+		return err
+	}
+	job.FileMetadata = merged
+	return nil
+}
+
+// RequiredAVU describes an AVU that's added to a job's metadata if its
+// attribute isn't already present.
+type RequiredAVU struct {
+	Attribute string
+	Unit      string
+
+	// Returns the value for the job.
+	Value func(job *Job) string
+}
+
+// DefaultRequiredAVUs are the AVUs added by AddRequiredMetadata().
+var DefaultRequiredAVUs = []RequiredAVU{
+	{Attribute: "ipc-analysis-id", Unit: "UUID", Value: func(job *Job) string { return job.AppID }},
+	{Attribute: "ipc-execution-id", Unit: "UUID", Value: func(job *Job) string { return job.InvocationID }},
+}
+
+// AddRequiredMetadataWith adds the AVUs from the rules whose attributes aren't
+// already present in Job.FileMetadata. Rules without a Value function and rules
+// whose value is empty for the job are skipped, since iRODS rejects AVUs with
+// empty values.
+func (job *Job) AddRequiredMetadataWith(rules []RequiredAVU) {
+	present := make(map[string]bool)
+	for _, md := range job.FileMetadata {
+		present[md.Attribute] = true
+	}
+	for _, rule := range rules {
+		if present[rule.Attribute] || rule.Value == nil {
+			continue
+		}
+		value := rule.Value(job)
+		if value == "" {
+			continue
+		}
+		present[rule.Attribute] = true
+		job.FileMetadata = append(job.FileMetadata, FileMetadata{
+			Attribute: rule.Attribute,
+			Value:     value,
+			Unit:      rule.Unit,
+		})
+	}
+}
@@ -0,0 +1,159 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFileMetadataValidate(t *testing.T) {
+	cases := []struct {
+		md    FileMetadata
+		valid bool
+	}{
+		{FileMetadata{"attr", "value", "unit"}, true},
+		{FileMetadata{"attr", "value, with a comma", ""}, false},
+		{FileMetadata{"attr,1", "value", ""}, false},
+		{FileMetadata{"", "value", "unit"}, false},
+		{FileMetadata{" ", "value", "unit"}, false},
+		{FileMetadata{"attr", "", "unit"}, false},
+		{FileMetadata{"attr", "line1\nline2", "unit"}, false},
+		{FileMetadata{"attr", strings.Repeat("x", MaxAVULength+1), "unit"}, false},
+	}
+	for _, c := range cases {
+		err := c.md.Validate()
+		if (err == nil) != c.valid {
+			t.Errorf("Validate() returned %v for %#v", err, c.md)
+		}
+	}
+}
+
+func TestValidateMetadata(t *testing.T) {
+	s := _inittests(t, false)
+	if err := s.ValidateMetadata(); err != nil {
+		t.Errorf("ValidateMetadata() returned an error for the test submission: %s", err)
+	}
+
+	md := []FileMetadata{
+		{"attr1", "value1", "unit1"},
+		{"", "value2", ""},
+		{"attr1", "value1", "unit1"},
+	}
+	err := ValidateMetadata(md)
+	if err == nil {
+		t.Fatal("ValidateMetadata() did not return an error")
+	}
+	var avuErr *AVUError
+	if !errors.As(err, &avuErr) || avuErr.Index != 1 {
+		t.Errorf("ValidateMetadata() returned %v", err)
+	}
+	if !strings.Contains(err.Error(), "AVU 2 (attr1): duplicates AVU 0") {
+		t.Errorf("ValidateMetadata() didn't report the duplicate: %s", err)
+	}
+}
+
+func TestDedupeMetadata(t *testing.T) {
+	md := []FileMetadata{{"a", "1", ""}, {"a", "2", ""}, {"a", "1", ""}}
+	expected := []FileMetadata{{"a", "1", ""}, {"a", "2", ""}}
+	if actual := DedupeMetadata(md); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("DedupeMetadata() returned %#v", actual)
+	}
+}
+
+func TestMergeMetadata(t *testing.T) {
+	base := []FileMetadata{{"a", "1", ""}, {"b", "1", ""}, {"a", "2", ""}}
+	additions := []FileMetadata{{"a", "3", ""}, {"c", "1", ""}, {"b", "1", ""}}
+	cases := []struct {
+		policy   MergePolicy
+		expected []FileMetadata
+	}{
+		{MergeKeepFirst, []FileMetadata{{"a", "1", ""}, {"b", "1", ""}, {"a", "2", ""}, {"c", "1", ""}}},
+		{MergeOverride, []FileMetadata{{"a", "3", ""}, {"b", "1", ""}, {"c", "1", ""}}},
+		{MergeAppend, []FileMetadata{{"a", "1", ""}, {"b", "1", ""}, {"a", "2", ""}, {"a", "3", ""}, {"c", "1", ""}}},
+	}
+	for _, c := range cases {
+		actual, err := MergeMetadata(base, additions, c.policy)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("MergeMetadata() with %s returned\n%#v\ninstead of\n%#v", c.policy, actual, c.expected)
+		}
+	}
+	if len(base) != 3 || base[0].Value != "1" {
+		t.Error("MergeMetadata() modified the base list")
+	}
+	if _, err := MergeMetadata(base, additions, "bogus"); err == nil {
+		t.Error("MergeMetadata() did not return an error for an unknown policy")
+	}
+}
+
+func TestRequiredMetadataRules(t *testing.T) {
+	rules := append([]RequiredAVU{}, DefaultRequiredAVUs...)
+	rules = append(rules, RequiredAVU{
+		Attribute: "ipc-submitter",
+		Value:     func(job *Job) string { return job.Submitter },
+	})
+	j, err := NewJob([]byte(`{"app_id": "app", "uuid": "exec", "username": "user"}`), WithRequiredMetadata(rules...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []FileMetadata{
+		{"ipc-analysis-id", "app", "UUID"},
+		{"ipc-execution-id", "exec", "UUID"},
+		{"ipc-submitter", "user", ""},
+	}
+	if !reflect.DeepEqual(j.FileMetadata, expected) {
+		t.Errorf("the job's metadata was %#v", j.FileMetadata)
+	}
+}
+
+func TestRequiredMetadataSkipped(t *testing.T) {
+	rules := []RequiredAVU{
+		{Attribute: "no-value-func", Unit: "UUID"},
+		{Attribute: "empty", Value: func(job *Job) string { return "" }},
+		{Attribute: "submitter", Value: func(job *Job) string { return job.Submitter }},
+	}
+	j, err := NewJob([]byte(`{"username": "user"}`), WithRequiredMetadata(rules...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []FileMetadata{{"submitter", "user", ""}}
+	if !reflect.DeepEqual(j.FileMetadata, expected) {
+		t.Errorf("the job's metadata was %#v instead of %#v", j.FileMetadata, expected)
+	}
+
+	j, err = NewJob([]byte(`{"username": "user"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(j.FileMetadata) != 0 {
+		t.Errorf("empty required AVUs were added: %#v", j.FileMetadata)
+	}
+	if err = j.ValidateMetadata(); err != nil {
+		t.Errorf("ValidateMetadata() returned an error: %s", err)
+	}
+}
+
+func TestCheckedArguments(t *testing.T) {
+	metadata := MetadataArgs{
+		{"ipc-app-name", "Word Count, v2", ""},
+		{"attr", "value", "unit"},
+	}
+	if actual := metadata[0].Argument(); actual != nil {
+		t.Errorf("Argument() returned %#v for an AVU containing a comma", actual)
+	}
+	expected := []string{"-m", "attr,value,unit"}
+	actual, err := metadata.CheckedArguments()
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("CheckedArguments() returned %#v instead of %#v", actual, expected)
+	}
+	var avuErr *AVUError
+	if !errors.As(err, &avuErr) || avuErr.Index != 0 {
+		t.Errorf("CheckedArguments() returned the error %v", err)
+	}
+	if actual := metadata.FileMetadataArguments(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("FileMetadataArguments() returned %#v instead of %#v", actual, expected)
+	}
+}
@@ -0,0 +1,294 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// validMountModes contains the options that docker accepts in the mode field of
+// a volume specification.
+var validMountModes = map[string]bool{
+	"ro":         true,
+	"rw":         true,
+	"z":          true,
+	"Z":          true,
+	"shared":     true,
+	"rshared":    true,
+	"slave":      true,
+	"rslave":     true,
+	"private":    true,
+	"rprivate":   true,
+	"nocopy":     true,
+	"consistent": true,
+	"cached":     true,
+	"delegated":  true,
+}
+
+// propagationModes contains the mount propagation options. At most one of them
+// may appear in a volume specification.
+var propagationModes = map[string]bool{
+	"shared":   true,
+	"rshared":  true,
+	"slave":    true,
+	"rslave":   true,
+	"private":  true,
+	"rprivate": true,
+}
+
+// splitModes splits a comma-separated mode string into its options, dropping
+// empty entries.
+func splitModes(mode string) []string {
+	var modes []string
+	for _, m := range strings.Split(mode, ",") {
+		if m = strings.TrimSpace(m); m != "" {
+			modes = append(modes, m)
+		}
+	}
+	return modes
+}
+
+// ParseVolume parses a volume specification in the format accepted by the -v
+// option of docker run ("container", "container:mode", "host:container" or
+// "host:container:mode") into a Volume. A two-part specification is a container
+// path and a mode when the second part isn't an absolute path, as docker does
+// for anonymous volumes. The ro and rw options are recorded in the ReadOnly
+// field; any other options are kept in the Mode field. The returned Volume is
+// validated before it's returned.
+func ParseVolume(spec string) (Volume, error) {
+	var v Volume
+	var mode string
+	parts := strings.Split(strings.TrimSpace(spec), ":")
+	switch len(parts) {
+	case 1:
+		v.ContainerPath = parts[0]
+	case 2:
+		if path.IsAbs(parts[0]) && !path.IsAbs(parts[1]) {
+			v.ContainerPath, mode = parts[0], parts[1]
+		} else {
+			v.HostPath, v.ContainerPath = parts[0], parts[1]
+		}
+	case 3:
+		v.HostPath, v.ContainerPath, mode = parts[0], parts[1], parts[2]
+	default:
+		return Volume{}, fmt.Errorf("invalid volume spec %q", spec)
+	}
+
+	var modes []string
+	readWrite := false
+	for _, m := range splitModes(mode) {
+		switch m {
+		case "ro":
+			v.ReadOnly = true
+		case "rw":
+			readWrite = true
+		default:
+			modes = append(modes, m)
+		}
+	}
+	if v.ReadOnly && readWrite {
+		return Volume{}, fmt.Errorf("volume spec %q is both read-only and read-write", spec)
+	}
+	v.Mode = strings.Join(modes, ",")
+
+	if err := v.Validate(); err != nil {
+		return Volume{}, err
+	}
+	return v, nil
+}
+
+// modes returns the complete list of options for the volume, including ro if
+// the volume is read-only. Duplicate options and rw are left out.
+func (v *Volume) modes() []string {
+	var modes []string
+	seen := make(map[string]bool)
+	if v.ReadOnly {
+		modes = append(modes, "ro")
+		seen["ro"] = true
+	}
+	for _, m := range splitModes(v.Mode) {
+		if m == "rw" || seen[m] {
+			continue
+		}
+		seen[m] = true
+		modes = append(modes, m)
+	}
+	return modes
+}
+
+// String returns the volume in the canonical "host:container:mode" format
+// accepted by docker run. The host path and mode are omitted when they're
+// empty, so an anonymous volume with a mode is rendered as "container:mode".
+func (v Volume) String() string {
+	parts := []string{v.ContainerPath}
+	if v.HostPath != "" {
+		parts = []string{v.HostPath, v.ContainerPath}
+	}
+	if modes := v.modes(); len(modes) > 0 {
+		parts = append(parts, strings.Join(modes, ","))
+	}
+	return strings.Join(parts, ":")
+}
+
+// Validate returns an error if the volume's paths aren't absolute or its mode
+// contains unknown or contradictory options.
+func (v *Volume) Validate() error {
+	if v.ContainerPath == "" {
+		return errors.New("volume container path is empty")
+	}
+	if !path.IsAbs(v.ContainerPath) {
+		return fmt.Errorf("volume container path %q is not absolute", v.ContainerPath)
+	}
+	if path.Clean(v.ContainerPath) == "/" {
+		return errors.New("volume container path may not be /")
+	}
+	if v.HostPath != "" && !path.IsAbs(v.HostPath) {
+		return fmt.Errorf("volume host path %q is not absolute", v.HostPath)
+	}
+
+	var propagation, label string
+	readOnly, readWrite := v.ReadOnly, false
+	for _, m := range splitModes(v.Mode) {
+		if !validMountModes[m] {
+			return fmt.Errorf("volume mode %q is not supported", m)
+		}
+		readOnly = readOnly || m == "ro"
+		readWrite = readWrite || m == "rw"
+		if readOnly && readWrite {
+			return fmt.Errorf("volume %s is both read-only and read-write", v.ContainerPath)
+		}
+		if propagationModes[m] {
+			if propagation != "" && propagation != m {
+				return fmt.Errorf("volume %s has conflicting propagation modes %s and %s", v.ContainerPath, propagation, m)
+			}
+			propagation = m
+		}
+		if m == "z" || m == "Z" {
+			if label != "" && label != m {
+				return fmt.Errorf("volume %s has conflicting SELinux labels z and Z", v.ContainerPath)
+			}
+			label = m
+		}
+	}
+	return nil
+}
+
+// MountConflict describes two mounts in a step's container that use the same
+// container path or where one of them is nested inside the other.
+type MountConflict struct {
+	StepIndex   int    // The index of the step in Job.Steps.
+	Path        string // The container path of the first mount.
+	Source      string // Describes where the first mount came from.
+	OtherPath   string // The container path of the second mount.
+	OtherSource string // Describes where the second mount came from.
+}
+
+// String returns a human readable description of the conflict.
+func (c MountConflict) String() string {
+	if c.Path == c.OtherPath {
+		return fmt.Sprintf("step %d: %s and %s are both mounted at %s", c.StepIndex, c.Source, c.OtherSource, c.Path)
+	}
+	return fmt.Sprintf("step %d: %s at %s overlaps %s at %s", c.StepIndex, c.Source, c.Path, c.OtherSource, c.OtherPath)
+}
+
+// mountPoint is a container path along with a description of what put it there.
+type mountPoint struct {
+	path   string
+	source string
+}
+
+// isNested returns true if p1 and p2 are the same path or one contains the
+// other.
+func isNested(p1, p2 string) bool {
+	if p1 == p2 {
+		return true
+	}
+	return strings.HasPrefix(p1, strings.TrimSuffix(p2, "/")+"/") ||
+		strings.HasPrefix(p2, strings.TrimSuffix(p1, "/")+"/")
+}
+
+// mountPoints returns the container paths used by the step's container, in the
+// order in which they're checked for conflicts. The porklock configuration is
+// mounted into the layout's configuration directory, so nothing else may be
+// mounted on top of it.
+func (s *Step) mountPoints() []mountPoint {
+	c := &s.Component.Container
+	points := []mountPoint{
+		{path: path.Clean(c.WorkingDirectory()), source: "the working directory"},
+		{path: path.Clean(s.layout.ConfigDirectory()), source: "the configuration directory"},
+	}
+	for _, v := range c.Volumes {
+		if v.ContainerPath == "" {
+			continue
+		}
+		points = append(points, mountPoint{
+			path:   path.Clean(v.ContainerPath),
+			source: fmt.Sprintf("volume %s", v.String()),
+		})
+	}
+	for _, vf := range c.VolumesFrom {
+		if vf.ContainerPath == "" {
+			continue
+		}
+		points = append(points, mountPoint{
+			path:   path.Clean(vf.ContainerPath),
+			source: fmt.Sprintf("volumes from %s", vf.Name),
+		})
+	}
+	return points
+}
+
+// MountConflicts returns the mount conflicts found in each of the job's steps.
+// Volumes, data containers, the working directory and the configuration
+// directory are all checked against each other. The working directory and the
+// configuration directory aren't mounts themselves, so they're allowed to
+// overlap each other.
+func (job *Job) MountConflicts() []MountConflict {
+	var conflicts []MountConflict
+	for idx, step := range job.Steps {
+		points := step.mountPoints()
+		for i := 0; i < len(points); i++ {
+			for j := i + 1; j < len(points); j++ {
+				if i < 2 && j < 2 {
+					continue
+				}
+				if isNested(points[i].path, points[j].path) {
+					conflicts = append(conflicts, MountConflict{
+						StepIndex:   idx,
+						Path:        points[i].path,
+						Source:      points[i].source,
+						OtherPath:   points[j].path,
+						OtherSource: points[j].source,
+					})
+				}
+			}
+		}
+	}
+	return conflicts
+}
+
+// ValidateMounts validates every volume in the job and checks for mount
+// conflicts, returning all of the problems that it finds in a single error.
+func (job *Job) ValidateMounts() error {
+	var errs []error
+	for idx, step := range job.Steps {
+		for _, v := range step.Component.Container.Volumes {
+			if err := v.Validate(); err != nil {
+				errs = append(errs, fmt.Errorf("step %d: %w", idx, err))
+			}
+		}
+		for _, vf := range step.Component.Container.VolumesFrom {
+			if vf.ContainerPath != "" && !path.IsAbs(vf.ContainerPath) {
+				errs = append(errs, fmt.Errorf("step %d: volumes from %s: container path %q is not absolute", idx, vf.Name, vf.ContainerPath))
+			}
+			if vf.HostPath != "" && !path.IsAbs(vf.HostPath) {
+				errs = append(errs, fmt.Errorf("step %d: volumes from %s: host path %q is not absolute", idx, vf.Name, vf.HostPath))
+			}
+		}
+	}
+	for _, c := range job.MountConflicts() {
+		errs = append(errs, errors.New(c.String()))
+	}
+	return errors.Join(errs...)
+}
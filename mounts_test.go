@@ -0,0 +1,125 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVolume(t *testing.T) {
+	actual, err := ParseVolume("/host/path:/container/path:ro,Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Volume{
+		HostPath:      "/host/path",
+		ContainerPath: "/container/path",
+		ReadOnly:      true,
+		Mode:          "Z",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ParseVolume() returned %#v instead of %#v", actual, expected)
+	}
+
+	actual, err = ParseVolume("/container/path")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = Volume{ContainerPath: "/container/path"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ParseVolume() returned %#v instead of %#v", actual, expected)
+	}
+}
+
+func TestParseVolumeErrors(t *testing.T) {
+	specs := []string{
+		"",
+		"relative/path",
+		"/host:relative",
+		"host:/container",
+		"/host:/",
+		"/host:/container:ro,rw",
+		"/host:/container:bogus",
+		"/host:/container:shared,private",
+		"/host:/container:z,Z",
+		"/a:/b:ro:extra",
+	}
+	for _, spec := range specs {
+		if _, err := ParseVolume(spec); err == nil {
+			t.Errorf("ParseVolume(%q) did not return an error", spec)
+		}
+	}
+}
+
+func TestVolumeString(t *testing.T) {
+	specs := map[string]string{
+		"/host/path:/container/path":          "/host/path:/container/path",
+		"/host/path:/container/path:rw":       "/host/path:/container/path",
+		"/host/path:/container/path:Z,ro":     "/host/path:/container/path:ro,Z",
+		"/container/path":                     "/container/path",
+		":/container/path:ro":                 "/container/path:ro",
+		"/container/path:ro":                  "/container/path:ro",
+		"/container/path:nocopy,ro":           "/container/path:ro,nocopy",
+		"/host/path:/container/path:rslave,z": "/host/path:/container/path:rslave,z",
+	}
+	for spec, expected := range specs {
+		v, err := ParseVolume(spec)
+		if err != nil {
+			t.Errorf("ParseVolume(%q) returned an error: %s", spec, err)
+			continue
+		}
+		if actual := v.String(); actual != expected {
+			t.Errorf("String() returned '%s' instead of '%s'", actual, expected)
+		}
+	}
+}
+
+func TestMountConflicts(t *testing.T) {
+	s := _inittests(t, false)
+	conflicts := s.MountConflicts()
+	if len(conflicts) != 2 {
+		t.Fatalf("MountConflicts() returned %d conflicts instead of 2: %v", len(conflicts), conflicts)
+	}
+	for _, c := range conflicts {
+		if c.Path != c.OtherPath {
+			t.Errorf("conflict was between %s and %s instead of a single path", c.Path, c.OtherPath)
+		}
+	}
+
+	s.Steps[0].Component.Container.VolumesFrom = nil
+	if conflicts = s.MountConflicts(); len(conflicts) != 0 {
+		t.Errorf("MountConflicts() returned %v instead of no conflicts", conflicts)
+	}
+
+	s.Steps[0].Component.Container.Volumes = append(
+		s.Steps[0].Component.Container.Volumes,
+		Volume{HostPath: "/host/path3", ContainerPath: "/work/data"},
+		Volume{HostPath: "/host/path4", ContainerPath: "/configs"},
+	)
+	conflicts = s.MountConflicts()
+	if len(conflicts) != 2 {
+		t.Fatalf("MountConflicts() returned %d conflicts instead of 2: %v", len(conflicts), conflicts)
+	}
+	if conflicts[0].Path != "/work" || conflicts[0].OtherPath != "/work/data" {
+		t.Errorf("unexpected conflict: %s", conflicts[0])
+	}
+	if conflicts[1].Path != "/configs" || conflicts[1].OtherPath != "/configs" {
+		t.Errorf("unexpected conflict: %s", conflicts[1])
+	}
+	_inittests(t, false)
+}
+
+func TestValidateMounts(t *testing.T) {
+	s := _inittests(t, false)
+	if err := s.ValidateMounts(); err == nil {
+		t.Error("ValidateMounts() did not return an error")
+	}
+	s.Steps[0].Component.Container.VolumesFrom = nil
+	if err := s.ValidateMounts(); err != nil {
+		t.Errorf("ValidateMounts() returned an error: %s", err)
+	}
+	s.Steps[0].Component.Container.Volumes[0].Mode = "bogus"
+	if err := s.ValidateMounts(); err == nil {
+		t.Error("ValidateMounts() did not return an error")
+	}
+	_inittests(t, false)
+}
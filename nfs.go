@@ -0,0 +1,163 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+)
+
+// NFSConfig describes how the data store is mounted on execution nodes that
+// run jobs with RunOnNFS set.
+type NFSConfig struct {
+	// Maps iRODS zones to the directories they're mounted on. A key may also be
+	// an absolute iRODS path, in which case only that collection is mounted.
+	// When paths match more than one key, the longest one wins. If Mounts is
+	// empty, the job's IRODSBase is mapped to its NFSBase.
+	Mounts map[string]string
+}
+
+// nfsMount is an iRODS path prefix and the directory it's mounted on.
+type nfsMount struct {
+	prefix    string
+	mountPath string
+}
+
+// mounts returns the mounts for the job, longest prefix first.
+func (c *NFSConfig) mounts(job *Job) []nfsMount {
+	var mounts []nfsMount
+	if c != nil {
+		for key, mountPath := range c.Mounts {
+			prefix := key
+			if !strings.HasPrefix(prefix, "/") {
+				prefix = "/" + prefix
+			}
+			mounts = append(mounts, nfsMount{prefix: path.Clean(prefix), mountPath: path.Clean(mountPath)})
+		}
+	}
+	if len(mounts) == 0 && job.IRODSBase != "" && job.NFSBase != "" {
+		mounts = append(mounts, nfsMount{prefix: path.Clean(job.IRODSBase), mountPath: path.Clean(job.NFSBase)})
+	}
+	sort.Slice(mounts, func(i, j int) bool {
+		if len(mounts[i].prefix) != len(mounts[j].prefix) {
+			return len(mounts[i].prefix) > len(mounts[j].prefix)
+		}
+		return mounts[i].prefix < mounts[j].prefix
+	})
+	return mounts
+}
+
+// NFSPath returns the location of an iRODS path on the NFS mount. A trailing
+// slash is preserved. An error is returned if the path isn't inside any of the
+// mounts.
+func (job *Job) NFSPath(cfg *NFSConfig, irodsPath string) (string, error) {
+	cleaned := path.Clean(irodsPath)
+	for _, m := range cfg.mounts(job) {
+		if cleaned != m.prefix && !strings.HasPrefix(cleaned, m.prefix+"/") {
+			continue
+		}
+		mapped := path.Join(m.mountPath, strings.TrimPrefix(cleaned, m.prefix))
+		if strings.HasSuffix(irodsPath, "/") {
+			mapped += "/"
+		}
+		return mapped, nil
+	}
+	return "", fmt.Errorf("%s is not inside any of the NFS mounts", irodsPath)
+}
+
+// NFSOutputDirectory returns the output directory on the NFS mount when the job
+// runs on NFS, and OutputDirectory() otherwise.
+func (job *Job) NFSOutputDirectory(cfg *NFSConfig) (string, error) {
+	if !job.RunOnNFS {
+		return job.OutputDirectory(), nil
+	}
+	return job.NFSPath(cfg, job.OutputDirectory())
+}
+
+// TransferStep describes moving an input into the working directory or the
+// outputs out of it.
+type TransferStep struct {
+	// The path the data comes from: an iRODS path, an NFS path, or the working
+	// directory for outputs.
+	Source string
+
+	// The path the data goes to.
+	Destination string
+
+	// The porklock arguments that perform the transfer. Empty when the
+	// transfer is skipped.
+	Arguments []string
+
+	// True when the transfer isn't needed because the data is already on
+	// shared storage.
+	Skipped bool
+
+	// The AVUs that porklock would have added to the uploaded outputs. Only set
+	// for skipped output transfers; the caller adds them to Destination in
+	// iRODS after the job finishes.
+	Metadata []FileMetadata
+
+	// The entries that porklock would have left out of the upload. Only set for
+	// skipped output transfers; on NFS they're already in Destination, so the
+	// caller removes them after the job finishes.
+	Exclude *ExcludeList
+}
+
+// InputTransfers returns a transfer for every input that has a value. When the
+// job runs on NFS, the inputs are read from the NFS mount, so their transfers
+// are skipped and Source is the NFS path; the caller links or mounts them into
+// the working directory.
+func (job *Job) InputTransfers(cfg *NFSConfig) ([]TransferStep, error) {
+	var transfers []TransferStep
+	for _, input := range job.Inputs() {
+		if input.Value == "" {
+			continue
+		}
+		t := TransferStep{
+			Source:      input.IRODSPath(),
+			Destination: input.Source(),
+		}
+		if job.RunOnNFS {
+			nfsPath, err := job.NFSPath(cfg, input.IRODSPath())
+			if err != nil {
+				return nil, err
+			}
+			t.Source = nfsPath
+			t.Skipped = true
+		} else {
+			t.Arguments = input.Arguments(job.Submitter, job.FileMetadata)
+		}
+		transfers = append(transfers, t)
+	}
+	return transfers, nil
+}
+
+// OutputTransfer returns the transfer that uploads the job's outputs. When the
+// job runs on NFS, its working directory is expected to be the output
+// directory on the NFS mount, so the upload is skipped and Destination is the
+// NFS path. Skipping porklock also skips its metadata and exclude handling, so
+// the transfer's Metadata and Exclude describe what the caller has to do
+// instead.
+func (job *Job) OutputTransfer(cfg *NFSConfig, excludeFilePath string) (TransferStep, error) {
+	workingDir := job.layout.WorkingDirectory()
+	if len(job.Steps) > 0 {
+		workingDir = job.Steps[0].Component.Container.WorkingDirectory()
+	}
+	t := TransferStep{
+		Source:      workingDir,
+		Destination: job.OutputDirectory(),
+	}
+	if job.RunOnNFS {
+		dir, err := job.NFSOutputDirectory(cfg)
+		if err != nil {
+			return TransferStep{}, err
+		}
+		t.Destination = dir
+		t.Skipped = true
+		t.Metadata = append([]FileMetadata{}, job.FileMetadata...)
+		t.Exclude = job.ExcludeList()
+		return t, nil
+	}
+	t.Arguments = job.FinalOutputArguments(excludeFilePath)
+	return t, nil
+}
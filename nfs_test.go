@@ -0,0 +1,128 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNFSPath(t *testing.T) {
+	s := _inittests(t, false)
+	cfg := &NFSConfig{Mounts: map[string]string{
+		"iplant":              "/mnt/iplant",
+		"/iplant/home/shared": "/mnt/shared",
+	}}
+	cases := []struct {
+		irodsPath string
+		expected  string
+	}{
+		{"/iplant/home/wregglej/Acer-tree.txt", "/mnt/iplant/home/wregglej/Acer-tree.txt"},
+		{"/iplant/home/shared/data/", "/mnt/shared/data/"},
+		{"/iplant", "/mnt/iplant"},
+	}
+	for _, c := range cases {
+		actual, err := s.NFSPath(cfg, c.irodsPath)
+		if err != nil {
+			t.Errorf("NFSPath(%q) returned an error: %s", c.irodsPath, err)
+		}
+		if actual != c.expected {
+			t.Errorf("NFSPath(%q) returned '%s' instead of '%s'", c.irodsPath, actual, c.expected)
+		}
+	}
+	if _, err := s.NFSPath(cfg, "/iplantx/home"); err == nil {
+		t.Error("NFSPath() did not return an error for a path outside the mounts")
+	}
+}
+
+func TestNFSPathDefaultMount(t *testing.T) {
+	s := _inittests(t, false)
+	s.IRODSBase = "/iplant/home"
+	s.NFSBase = "/nfs/home"
+	actual, err := s.NFSPath(nil, "/iplant/home/wregglej/file.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if actual != "/nfs/home/wregglej/file.txt" {
+		t.Errorf("NFSPath() returned '%s'", actual)
+	}
+}
+
+func TestNFSOutputDirectory(t *testing.T) {
+	s := _inittests(t, false)
+	cfg := &NFSConfig{Mounts: map[string]string{"iplant": "/mnt/iplant"}}
+	dir, err := s.NFSOutputDirectory(cfg)
+	if err != nil || dir != s.OutputDirectory() {
+		t.Errorf("NFSOutputDirectory() returned '%s', %v without RunOnNFS", dir, err)
+	}
+	s.RunOnNFS = true
+	dir, err = s.NFSOutputDirectory(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "/mnt" + s.OutputDirectory()
+	if dir != expected {
+		t.Errorf("NFSOutputDirectory() returned '%s' instead of '%s'", dir, expected)
+	}
+}
+
+func TestInputTransfers(t *testing.T) {
+	s := _inittests(t, false)
+	cfg := &NFSConfig{Mounts: map[string]string{"iplant": "/mnt/iplant"}}
+	transfers, err := s.InputTransfers(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(transfers) != 7 {
+		t.Fatalf("InputTransfers() returned %d transfers instead of 7", len(transfers))
+	}
+	input := s.Steps[0].Config.Inputs[0]
+	expected := input.Arguments(s.Submitter, s.FileMetadata)
+	if transfers[0].Skipped || !reflect.DeepEqual(transfers[0].Arguments, expected) {
+		t.Errorf("InputTransfers() returned %#v", transfers[0])
+	}
+
+	s.RunOnNFS = true
+	transfers, err = s.InputTransfers(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tr := range transfers {
+		if !tr.Skipped || tr.Arguments != nil {
+			t.Errorf("the transfer %#v wasn't skipped", tr)
+		}
+	}
+	if transfers[0].Source != "/mnt/iplant/home/wregglej/Acer-tree.txt" || transfers[0].Destination != "Acer-tree.txt" {
+		t.Errorf("InputTransfers() returned %#v", transfers[0])
+	}
+
+	cfg.Mounts = map[string]string{"other": "/mnt/other"}
+	if _, err = s.InputTransfers(cfg); err == nil {
+		t.Error("InputTransfers() did not return an error for unmapped inputs")
+	}
+}
+
+func TestOutputTransfer(t *testing.T) {
+	s := _inittests(t, false)
+	cfg := &NFSConfig{Mounts: map[string]string{"iplant": "/mnt/iplant"}}
+	tr, err := s.OutputTransfer(cfg, "/path/to/exclude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tr.Skipped || tr.Source != "/work" || !reflect.DeepEqual(tr.Arguments, s.FinalOutputArguments("/path/to/exclude")) || tr.Metadata != nil || tr.Exclude != nil {
+		t.Errorf("OutputTransfer() returned %#v", tr)
+	}
+
+	s.RunOnNFS = true
+	tr, err = s.OutputTransfer(cfg, "/path/to/exclude")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !tr.Skipped || tr.Arguments != nil || tr.Destination != "/mnt"+s.OutputDirectory() {
+		t.Errorf("OutputTransfer() returned %#v", tr)
+	}
+	if len(s.FileMetadata) == 0 || !reflect.DeepEqual(tr.Metadata, s.FileMetadata) {
+		t.Errorf("Metadata was %#v instead of %#v", tr.Metadata, s.FileMetadata)
+	}
+	if tr.Exclude == nil || !reflect.DeepEqual(tr.Exclude.Entries(), s.ExcludeList().Entries()) || len(tr.Exclude.Entries()) == 0 {
+		t.Errorf("Exclude was %#v", tr.Exclude)
+	}
+}
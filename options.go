@@ -0,0 +1,207 @@
+package model
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// Clock returns the current time. Jobs use it for NowDate and SubmissionDate.
+type Clock func() time.Time
+
+// IDGenerator returns a new unique ID. Jobs use it for InvocationIDs.
+type IDGenerator func() string
+
+// options contains the settings that can be passed to the Job constructors.
+type options struct {
+	clock Clock
+	newID IDGenerator
+	utc   bool
+
+	logPath         string
+	filterFiles     []string
+	irodsBase       string
+	strict          bool
+	backwardsCompat []string
+	layout          *Layout
+	requiredAVUs    []RequiredAVU
+}
+
+// Option changes a setting used when constructing a Job.
+type Option func(*options)
+
+// WithClock sets the clock used for NowDate and SubmissionDate. Defaults to
+// time.Now.
+func WithClock(clock Clock) Option {
+	return func(o *options) {
+		o.clock = clock
+	}
+}
+
+// WithIDGenerator generates an InvocationID for job submissions that don't
+// contain one. Without it the InvocationID is left empty. Pass NewUUID for
+// random UUIDs.
+func WithIDGenerator(gen IDGenerator) Option {
+	return func(o *options) {
+		o.newID = gen
+	}
+}
+
+// WithUTC formats NowDate and SubmissionDate in UTC instead of the local time
+// zone, so that jobs constructed at the same moment on servers in different time
+// zones get the same directory names.
+func WithUTC() Option {
+	return func(o *options) {
+		o.utc = true
+	}
+}
+
+// WithLogPath sets the directory that HTCondor writes the job's logs to.
+func WithLogPath(logPath string) Option {
+	return func(o *options) {
+		o.logPath = logPath
+	}
+}
+
+// WithFilterFiles sets the files that are left out when the job's outputs are
+// uploaded.
+func WithFilterFiles(files ...string) Option {
+	return func(o *options) {
+		o.filterFiles = files
+	}
+}
+
+// WithIRODSBase sets the path in iRODS that users' home directories are in,
+// which is used to construct the default output directory.
+func WithIRODSBase(irodsBase string) Option {
+	return func(o *options) {
+		o.irodsBase = irodsBase
+	}
+}
+
+// WithStrictDecoding makes NewJob return an error if the job submission
+// contains any fields that the Job type doesn't have.
+func WithStrictDecoding() Option {
+	return func(o *options) {
+		o.strict = true
+	}
+}
+
+// WithBackwardsCompatPatterns sets the backwards compatible image patterns used
+// by the job's steps. Passing no patterns uses the defaults. Only the job being
+// constructed is affected; see Job.SetBackwardsCompat().
+func WithBackwardsCompatPatterns(patterns ...string) Option {
+	return func(o *options) {
+		o.backwardsCompat = patterns
+	}
+}
+
+// WithLayout sets the layout used for the job's run-time paths. Defaults to
+// DefaultLayout. See Job.SetLayout().
+func WithLayout(layout Layout) Option {
+	return func(o *options) {
+		o.layout = &layout
+	}
+}
+
+// WithRequiredMetadata replaces the rules used by Job.AddRequiredMetadata().
+// Include DefaultRequiredAVUs to add to the defaults rather than replace them.
+func WithRequiredMetadata(rules ...RequiredAVU) Option {
+	return func(o *options) {
+		o.requiredAVUs = append([]RequiredAVU{}, rules...)
+	}
+}
+
+// NewJob creates a new Job with the settings from the options. If data isn't
+// empty it's parsed as a JSON job submission, an InvocationID is generated if
+// the submission doesn't contain one and WithIDGenerator was passed, and the
+// job is sanitized and given the required metadata. The options are only used
+// during construction; they aren't kept on the job.
+func NewJob(data []byte, opts ...Option) (*Job, error) {
+	o := newOptions(opts)
+	var compat *BackwardsCompatClassifier
+	if len(o.backwardsCompat) > 0 {
+		var err error
+		if compat, err = NewBackwardsCompatClassifier(o.backwardsCompat); err != nil {
+			return nil, err
+		}
+	}
+
+	job := newJob(o)
+	if len(data) == 0 {
+		return job, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if o.strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(job); err != nil {
+		return nil, err
+	}
+	if decoder.More() {
+		return nil, errors.New("the job submission contains data after the JSON object")
+	}
+	if job.InvocationID == "" && o.newID != nil {
+		job.InvocationID = o.newID()
+	}
+	job.SetBackwardsCompat(compat)
+	if o.layout != nil {
+		job.SetLayout(*o.layout)
+	}
+	job.Sanitize()
+	rules := DefaultRequiredAVUs
+	if o.requiredAVUs != nil {
+		rules = o.requiredAVUs
+	}
+	job.AddRequiredMetadataWith(rules)
+	return job, nil
+}
+
+// newJob returns a Job with the settings from the options that can't fail.
+func newJob(o *options) *Job {
+	n := timestamp(o.clock, o.utc)
+	job := &Job{
+		NowDate:        n,
+		SubmissionDate: n,
+		ArchiveLogs:    true,
+		CondorLogPath:  o.logPath,
+		FilterFiles:    copyStrings(o.filterFiles),
+		IRODSBase:      o.irodsBase,
+	}
+	if o.layout != nil {
+		job.SetLayout(*o.layout)
+	}
+	return job
+}
+
+// newOptions applies the options to the defaults.
+func newOptions(opts []Option) *options {
+	o := &options{}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// timestamp returns the current time according to the clock, which defaults to
+// time.Now, formatted for NowDate and SubmissionDate.
+func timestamp(clock Clock, utc bool) string {
+	if clock == nil {
+		clock = time.Now
+	}
+	t := clock()
+	if utc {
+		t = t.UTC()
+	}
+	return t.Format(nowfmt)
+}
+
+// newID returns a new ID from the generator, which defaults to NewUUID.
+func newID(gen IDGenerator) string {
+	if gen == nil {
+		gen = NewUUID
+	}
+	return gen()
+}
@@ -0,0 +1,178 @@
+package model
+
+import (
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func fixedClock() time.Time {
+	return time.Date(2024, 3, 1, 12, 30, 45, 123000000, time.FixedZone("MST", -7*60*60))
+}
+
+func sequentialIDs() IDGenerator {
+	n := 0
+	return func() string {
+		n++
+		return []string{"id-1", "id-2", "id-3"}[n-1]
+	}
+}
+
+func TestNewJobWithClock(t *testing.T) {
+	j, err := NewJob(nil, WithClock(fixedClock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.NowDate != "2024-03-01-12-30-45.123" || j.SubmissionDate != j.NowDate {
+		t.Errorf("NowDate was '%s' and SubmissionDate was '%s'", j.NowDate, j.SubmissionDate)
+	}
+
+	j, err = NewJob(nil, WithClock(fixedClock), WithUTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.NowDate != "2024-03-01-19-30-45.123" {
+		t.Errorf("NowDate was '%s' instead of '2024-03-01-19-30-45.123'", j.NowDate)
+	}
+}
+
+func TestNewFromDataWithOptions(t *testing.T) {
+	if cfg == nil {
+		_initconfig(t)
+	}
+	data, err := os.ReadFile("test/test_submission.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	j, err := NewFromData(cfg, data, WithClock(fixedClock), WithUTC(), WithIDGenerator(sequentialIDs()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The submission contains a UUID, so the generator isn't used.
+	if j.InvocationID != "07b04ce2-7757-4b21-9e15-0b4c2f44be26" {
+		t.Errorf("InvocationID was '%s'", j.InvocationID)
+	}
+	expected := "Word_Count_analysis1__-2024-03-01-19-30-45.123"
+	if j.DirectoryName() != expected {
+		t.Errorf("DirectoryName() returned '%s' instead of '%s'", j.DirectoryName(), expected)
+	}
+
+	r, err := j.Relaunch(RelaunchOptions{Clock: fixedClock, UTC: true, NewID: sequentialIDs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.InvocationID != "id-1" || r.NowDate != j.NowDate {
+		t.Errorf("the relaunched job had InvocationID '%s' and NowDate '%s'", r.InvocationID, r.NowDate)
+	}
+}
+
+func TestNewAnalysisGeneratesInvocationID(t *testing.T) {
+	a, err := NewAnalysis(&AnalysisConfig{}, []byte(`{"name": "test"}`), WithIDGenerator(sequentialIDs()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.InvocationID != "id-1" {
+		t.Errorf("InvocationID was '%s' instead of 'id-1'", a.InvocationID)
+	}
+	for _, md := range a.FileMetadata {
+		if md.Attribute == "ipc-execution-id" && md.Value != "id-1" {
+			t.Errorf("ipc-execution-id was '%s' instead of 'id-1'", md.Value)
+		}
+	}
+
+	a, err = NewAnalysis(&AnalysisConfig{}, []byte(`{"name": "test"}`), WithIDGenerator(NewUUID))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(a.InvocationID) != 36 {
+		t.Errorf("InvocationID was '%s', which isn't a UUID", a.InvocationID)
+	}
+
+	// Without a generator the InvocationID is left alone.
+	a, err = NewAnalysis(&AnalysisConfig{}, []byte(`{"name": "test"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.InvocationID != "" {
+		t.Errorf("InvocationID was '%s' instead of an empty string", a.InvocationID)
+	}
+}
+
+func TestOptionsNotKept(t *testing.T) {
+	data := []byte(`{"name": "test", "steps": [{"component": {"name": "wc"}}]}`)
+	j, err := NewJob(data, WithIDGenerator(sequentialIDs()), WithRequiredMetadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.InvocationID != "id-1" || len(j.FileMetadata) != 0 {
+		t.Errorf("NewJob() set InvocationID to '%s' and FileMetadata to %#v", j.InvocationID, j.FileMetadata)
+	}
+
+	// Clones and relaunched jobs don't inherit the constructor's settings.
+	r, err := j.Clone().Relaunch(RelaunchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(r.InvocationID) != 36 {
+		t.Errorf("InvocationID was '%s', which isn't a UUID", r.InvocationID)
+	}
+}
+
+func TestNewJob(t *testing.T) {
+	j, err := NewJob(nil, WithLogPath("/logs"), WithFilterFiles("a", "b"), WithIRODSBase("/zone/home"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if j.CondorLogPath != "/logs" || j.IRODSBase != "/zone/home" || len(j.FilterFiles) != 2 {
+		t.Errorf("NewJob() returned %#v", j)
+	}
+	if !j.ArchiveLogs || j.NowDate == "" {
+		t.Error("NewJob() didn't set the defaults")
+	}
+	if j.InvocationID != "" {
+		t.Error("NewJob() set the InvocationID without a job submission")
+	}
+}
+
+func TestNewJobStrictDecoding(t *testing.T) {
+	data := []byte(`{"name": "test", "not_a_field": true}`)
+	if _, err := NewJob(data); err != nil {
+		t.Errorf("NewJob() returned an error without strict decoding: %s", err)
+	}
+	if _, err := NewJob(data, WithStrictDecoding()); err == nil {
+		t.Error("NewJob() did not return an error for an unknown field with strict decoding")
+	}
+	if _, err := NewJob([]byte(`{"name": "test"} {}`)); err == nil {
+		t.Error("NewJob() did not return an error for trailing data")
+	}
+}
+
+func TestNewMatchesNewJob(t *testing.T) {
+	if cfg == nil {
+		_initconfig(t)
+	}
+	data, err := os.ReadFile("test/test_submission.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromViper, err := NewFromData(cfg, data, WithClock(fixedClock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromOptions, err := NewJob(data,
+		WithLogPath("/path/to/logs"),
+		WithFilterFiles("foo", "bar", "baz", "blippy"),
+		WithIRODSBase("/path/to/irodsbase"),
+		WithClock(fixedClock),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fromViper.OutputDirectory() != fromOptions.OutputDirectory() {
+		t.Errorf("the output directories were '%s' and '%s'", fromViper.OutputDirectory(), fromOptions.OutputDirectory())
+	}
+	if fromViper.CondorLogPath != fromOptions.CondorLogPath || !reflect.DeepEqual(fromViper.FilterFiles, fromOptions.FilterFiles) {
+		t.Error("NewFromData() and NewJob() returned different settings")
+	}
+}
@@ -0,0 +1,174 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+)
+
+// OutputDirStrategy determines how the output directory of a job is chosen.
+type OutputDirStrategy string
+
+const (
+	// OutputDirTimestamp uses OutputDirectory() unchanged, which appends
+	// NowDate to the job's name. It's an error if the directory already exists.
+	OutputDirTimestamp OutputDirStrategy = "timestamp"
+
+	// OutputDirTimestampID appends NowDate and a short ID taken from the
+	// InvocationID to the job's name, so that jobs with the same name submitted
+	// in the same millisecond get different directories. It's an error if the
+	// directory already exists.
+	OutputDirTimestampID OutputDirStrategy = "timestamp-id"
+
+	// OutputDirNumericSuffix uses OutputDirectory(), adding -1, -2 and so on
+	// to the end until it finds a directory that doesn't exist. It's the only
+	// strategy that avoids collisions for an explicit OutputDir that has
+	// CreateOutputSubdir turned off, since the others can't rename it.
+	OutputDirNumericSuffix OutputDirStrategy = "numeric-suffix"
+)
+
+// DefaultMaxOutputDirAttempts is the number of directories that
+// OutputDirNumericSuffix tries when OutputDirResolver.MaxAttempts isn't set.
+const DefaultMaxOutputDirAttempts = 100
+
+// shortIDLength is the number of characters of the InvocationID that
+// OutputDirTimestampID uses.
+const shortIDLength = 8
+
+// ErrOutputDirExists is returned, wrapped, when the output directory chosen for
+// a job already exists.
+var ErrOutputDirExists = errors.New("the output directory already exists")
+
+// ExistsFunc reports whether a path exists. Callers usually check the data
+// store.
+type ExistsFunc func(p string) (bool, error)
+
+// OutputDirResolver chooses a unique output directory for a job.
+type OutputDirResolver struct {
+	// Defaults to OutputDirTimestamp.
+	Strategy OutputDirStrategy
+
+	// Checks whether a candidate directory already exists. Required for
+	// OutputDirNumericSuffix. The other strategies skip the check if it's nil.
+	Exists ExistsFunc
+
+	// The number of directories OutputDirNumericSuffix will try. Defaults to
+	// DefaultMaxOutputDirAttempts.
+	MaxAttempts int
+
+	// Generates the ID used by OutputDirTimestampID for jobs that don't have an
+	// InvocationID. Defaults to NewUUID.
+	NewID IDGenerator
+}
+
+// outputDirectoryNamed returns the output directory for the job, using name
+// for the directory that's created for it. OutputDirectory() passes
+// DirectoryName().
+func (job *Job) outputDirectoryNamed(name string) string {
+	switch {
+	case job.OutputDir == "":
+		return path.Join(job.IRODSBase, job.Submitter, "analyses", name)
+	case job.CreateOutputSubdir:
+		return path.Join(job.OutputDir, name)
+	}
+	return strings.TrimSuffix(job.OutputDir, "/")
+}
+
+// shortID returns the first few characters of the job's InvocationID, or of a
+// new ID from the generator if the job doesn't have one.
+func (job *Job) shortID(gen IDGenerator) string {
+	id := job.InvocationID
+	if id == "" {
+		id = newID(gen)
+	}
+	id = strings.ReplaceAll(id, "-", "")
+	if len(id) > shortIDLength {
+		id = id[:shortIDLength]
+	}
+	return sanitize(id)
+}
+
+// check reports whether the directory exists, treating it as missing when there's
+// no existence check.
+func (r *OutputDirResolver) check(dir string) (bool, error) {
+	if r.Exists == nil {
+		return false, nil
+	}
+	exists, err := r.Exists(dir)
+	if err != nil {
+		return false, fmt.Errorf("unable to check whether %s exists: %w", dir, err)
+	}
+	return exists, nil
+}
+
+// Resolve returns the output directory for the job according to the strategy.
+// The job isn't modified.
+func (r *OutputDirResolver) Resolve(job *Job) (string, error) {
+	strategy := r.Strategy
+	if strategy == "" {
+		strategy = OutputDirTimestamp
+	}
+
+	var dir string
+	switch strategy {
+	case OutputDirTimestamp:
+		dir = job.OutputDirectory()
+	case OutputDirTimestampID:
+		name := job.DirectoryName()
+		dir = job.outputDirectoryNamed(fmt.Sprintf("%s-%s", name, job.shortID(r.NewID)))
+	case OutputDirNumericSuffix:
+		return r.resolveNumeric(job)
+	default:
+		return "", fmt.Errorf("unsupported output directory strategy %q", strategy)
+	}
+
+	exists, err := r.check(dir)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		return "", fmt.Errorf("%w: %s", ErrOutputDirExists, dir)
+	}
+	return dir, nil
+}
+
+// resolveNumeric implements OutputDirNumericSuffix.
+func (r *OutputDirResolver) resolveNumeric(job *Job) (string, error) {
+	if r.Exists == nil {
+		return "", errors.New("the numeric suffix strategy requires an existence check")
+	}
+	maxAttempts := r.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxOutputDirAttempts
+	}
+
+	base := job.OutputDirectory()
+	for i := 0; i < maxAttempts; i++ {
+		dir := base
+		if i > 0 {
+			dir = fmt.Sprintf("%s-%d", base, i)
+		}
+		exists, err := r.check(dir)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return dir, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s and the next %d numbered directories", ErrOutputDirExists, base, maxAttempts-1)
+}
+
+// Apply resolves the job's output directory and stores it in OutputDir with
+// CreateOutputSubdir turned off, so that OutputDirectory() returns it from then
+// on.
+func (r *OutputDirResolver) Apply(job *Job) error {
+	dir, err := r.Resolve(job)
+	if err != nil {
+		return err
+	}
+	job.OutputDir = dir
+	job.CreateOutputSubdir = false
+	return nil
+}
@@ -0,0 +1,109 @@
+package model
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestResolveTimestamp(t *testing.T) {
+	s := _inittests(t, false)
+	s.OutputDir = ""
+	fs := newMemoryFS()
+	r := &OutputDirResolver{Exists: fs.Exists}
+	dir, err := r.Resolve(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dir != s.OutputDirectory() {
+		t.Errorf("Resolve() returned '%s' instead of '%s'", dir, s.OutputDirectory())
+	}
+
+	fs.Add(dir)
+	if _, err = r.Resolve(s); !errors.Is(err, ErrOutputDirExists) {
+		t.Errorf("Resolve() returned %v instead of ErrOutputDirExists", err)
+	}
+}
+
+func TestResolveTimestampID(t *testing.T) {
+	s := _inittests(t, false)
+	s.OutputDir = ""
+	r := &OutputDirResolver{Strategy: OutputDirTimestampID}
+	dir, err := r.Resolve(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := s.OutputDirectory() + "-07b04ce2"
+	if dir != expected {
+		t.Errorf("Resolve() returned '%s' instead of '%s'", dir, expected)
+	}
+
+	// Jobs with the same name and timestamp get different directories.
+	other := s.Clone()
+	other.InvocationID = "aaaaaaaa-7757-4b21-9e15-0b4c2f44be26"
+	otherDir, err := r.Resolve(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if otherDir == dir {
+		t.Errorf("Resolve() returned '%s' for both jobs", dir)
+	}
+}
+
+func TestResolveNumericSuffix(t *testing.T) {
+	s := _inittests(t, false)
+	s.OutputDir = "/iplant/home/wregglej/results"
+	s.CreateOutputSubdir = false
+	fs := newMemoryFS("/iplant/home/wregglej/results", "/iplant/home/wregglej/results-1")
+	r := &OutputDirResolver{Strategy: OutputDirNumericSuffix, Exists: fs.Exists}
+	if err := r.Apply(s); err != nil {
+		t.Fatal(err)
+	}
+	if s.OutputDirectory() != "/iplant/home/wregglej/results-2" {
+		t.Errorf("Apply() set the output directory to '%s'", s.OutputDirectory())
+	}
+
+	r.MaxAttempts = 2
+	s.OutputDir = "/iplant/home/wregglej/results"
+	if err := r.Apply(s); !errors.Is(err, ErrOutputDirExists) {
+		t.Errorf("Apply() returned %v instead of ErrOutputDirExists", err)
+	}
+	if s.OutputDir != "/iplant/home/wregglej/results" {
+		t.Error("Apply() modified the job after failing")
+	}
+
+	if _, err := (&OutputDirResolver{Strategy: OutputDirNumericSuffix}).Resolve(s); err == nil {
+		t.Error("Resolve() did not return an error without an existence check")
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	s := _inittests(t, false)
+	failing := func(p string) (bool, error) { return false, errors.New("unavailable") }
+	r := &OutputDirResolver{Exists: failing}
+	if _, err := r.Resolve(s); err == nil || !strings.Contains(err.Error(), "unavailable") {
+		t.Errorf("Resolve() returned %v", err)
+	}
+	r = &OutputDirResolver{Strategy: "bogus"}
+	if _, err := r.Resolve(s); err == nil {
+		t.Error("Resolve() did not return an error for an unknown strategy")
+	}
+}
+
+func TestMemoryFS(t *testing.T) {
+	fs := newMemoryFS("/a/b/c")
+	for _, p := range []string{"/a", "/a/b", "/a/b/c/"} {
+		if exists, _ := fs.Exists(p); !exists {
+			t.Errorf("Exists(%q) returned false", p)
+		}
+	}
+	if exists, _ := fs.Exists("/a/c"); exists {
+		t.Error("Exists(\"/a/c\") returned true")
+	}
+	if !fs.Create("/a/d") || fs.Create("/a/d") {
+		t.Error("Create() didn't report whether the path was new")
+	}
+	if strings.Join(fs.Paths(), ",") != "/a,/a/b,/a/b/c,/a/d" {
+		t.Errorf("Paths() returned %#v", fs.Paths())
+	}
+}
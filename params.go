@@ -0,0 +1,133 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ArgumentStyle determines how an option name and its value are placed on the
+// command line.
+type ArgumentStyle int
+
+const (
+	// SeparateArguments renders options as two arguments: "--name value".
+	SeparateArguments ArgumentStyle = iota
+
+	// JoinedArguments renders options as a single argument: "--name=value".
+	JoinedArguments
+)
+
+// ParamRenderer converts StepParams into command-line arguments based on their
+// types.
+//
+// Flags are rendered as just their name when their value is true or empty and
+// are left out when it's explicitly false. Environment variable parameters
+// aren't rendered on the command line at all; see Step.EnvironmentWithParams().
+// Other typed parameters with an empty value are rendered as just their name,
+// the way the apps service sends them, unless OmitEmpty is set. An option name
+// ending in = is always joined to its value. Parameters without a type are
+// rendered as their name followed by their value, skipping whichever of the two
+// is empty.
+type ParamRenderer struct {
+	Style ArgumentStyle
+
+	// If OmitEmpty is set, typed parameters and flags with an empty value are
+	// left out entirely, treating them as optional parameters that the user
+	// didn't fill in.
+	OmitEmpty bool
+
+	// A multi-value parameter is a run of parameters with the same non-empty ID
+	// where only the first one has a name. If RepeatNames is set, the name is
+	// repeated before every value.
+	RepeatNames bool
+}
+
+// DefaultParamRenderer is the renderer used by Step.Arguments().
+var DefaultParamRenderer = ParamRenderer{Style: SeparateArguments}
+
+// parseFlag interprets the value of a Flag parameter. The second return value
+// is false if the value isn't a recognized boolean.
+func parseFlag(value string) (bool, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "true", "1", "yes", "on":
+		return true, true
+	case "false", "0", "no", "off":
+		return false, true
+	}
+	return false, false
+}
+
+// option renders an option name and value according to the style.
+func (r *ParamRenderer) option(name, value string) []string {
+	switch {
+	case name == "":
+		return []string{value}
+	case value == "":
+		return []string{name}
+	case strings.HasSuffix(name, "="):
+		return []string{name + value}
+	case r.Style == JoinedArguments:
+		return []string{fmt.Sprintf("%s=%s", name, value)}
+	}
+	return []string{name, value}
+}
+
+// Render returns the command-line arguments for the parameters, which should
+// already be sorted.
+func (r *ParamRenderer) Render(params []StepParam) []string {
+	var args []string
+	var groupID, groupName string
+	for _, p := range params {
+		name := strings.TrimSpace(p.Name)
+		value := strings.TrimSpace(p.Value)
+
+		if name != "" || p.ID == "" || p.ID != groupID {
+			groupID, groupName = p.ID, name
+		} else if r.RepeatNames {
+			name = groupName
+		}
+
+		switch {
+		case p.Type == "":
+			// Untyped parameters keep the original behavior.
+			if name != "" {
+				args = append(args, name)
+			}
+			if value != "" {
+				args = append(args, value)
+			}
+		case p.ParsedType() == ParamTypeEnvironmentVariable:
+			continue
+		case value == "":
+			if !r.OmitEmpty && name != "" {
+				args = append(args, name)
+			}
+		case p.ParsedType() == ParamTypeFlag:
+			on, ok := parseFlag(value)
+			if ok && on && name != "" {
+				args = append(args, name)
+			} else if !ok {
+				args = append(args, r.option(name, value)...)
+			}
+		default:
+			args = append(args, r.option(name, value)...)
+		}
+	}
+	return args
+}
+
+// EnvironmentWithParams returns the step's environment merged with the
+// environment variable parameters. Parameters override the Environment field
+// when they set the same variable.
+func (s *Step) EnvironmentWithParams() StepEnvironment {
+	env := make(StepEnvironment)
+	for k, v := range s.Environment {
+		env[k] = v
+	}
+	for _, p := range s.Config.Params {
+		if p.ParsedType() == ParamTypeEnvironmentVariable && strings.TrimSpace(p.Name) != "" {
+			env[strings.TrimSpace(p.Name)] = p.Value
+		}
+	}
+	return env
+}
@@ -0,0 +1,147 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func typedParams() []StepParam {
+	return []StepParam{
+		{ID: "1", Name: "--verbose", Value: "true", Order: 1, Type: "Flag"},
+		{ID: "2", Name: "--quiet", Value: "false", Order: 2, Type: "Flag"},
+		{ID: "3", Name: "--level", Value: "3", Order: 3, Type: "Integer"},
+		{ID: "4", Name: "--optional", Value: "", Order: 4, Type: "Text"},
+		{ID: "5", Name: "--prefix=", Value: "out", Order: 5, Type: "Text"},
+		{ID: "6", Name: "TOOL_HOME", Value: "/opt/tool", Order: 6, Type: "EnvironmentVariable"},
+		{ID: "7", Name: "-i", Value: "a.txt", Order: 7, Type: "MultiFileSelector"},
+		{ID: "7", Name: "", Value: "b.txt", Order: 7, Type: "MultiFileSelector"},
+		{ID: "8", Name: "", Value: "positional", Order: 8, Type: "Text"},
+	}
+}
+
+func TestParamRendererRender(t *testing.T) {
+	r := ParamRenderer{}
+	actual := r.Render(typedParams())
+	expected := []string{
+		"--verbose",
+		"--level", "3",
+		"--optional",
+		"--prefix=out",
+		"-i", "a.txt", "b.txt",
+		"positional",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Render() returned:\n\t%#v\ninstead of:\n\t%#v", actual, expected)
+	}
+
+	r = ParamRenderer{Style: JoinedArguments, RepeatNames: true, OmitEmpty: true}
+	actual = r.Render(typedParams())
+	expected = []string{
+		"--verbose",
+		"--level=3",
+		"--prefix=out",
+		"-i=a.txt", "-i=b.txt",
+		"positional",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Render() returned:\n\t%#v\ninstead of:\n\t%#v", actual, expected)
+	}
+}
+
+func TestParamRendererEmptyValues(t *testing.T) {
+	params := []StepParam{
+		{Name: "-v", Value: "", Order: 1, Type: "Flag"},
+		{Name: "--opt", Value: "", Order: 2, Type: "Text"},
+		{Name: "-q", Value: "0", Order: 3, Type: "Flag"},
+	}
+	r := ParamRenderer{}
+	expected := []string{"-v", "--opt"}
+	if actual := r.Render(params); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Render() returned:\n\t%#v\ninstead of:\n\t%#v", actual, expected)
+	}
+
+	r = ParamRenderer{OmitEmpty: true}
+	if actual := r.Render(params); len(actual) != 0 {
+		t.Errorf("Render() returned %#v with OmitEmpty set", actual)
+	}
+}
+
+func TestParamRendererRepeatNamesWithoutID(t *testing.T) {
+	r := ParamRenderer{RepeatNames: true}
+	params := []StepParam{
+		{Name: "-o", Value: "out.txt", Order: 1, Type: "Text"},
+		{Name: "", Value: "positional", Order: 2, Type: "Text"},
+	}
+	actual := r.Render(params)
+	expected := []string{"-o", "out.txt", "positional"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Render() returned:\n\t%#v\ninstead of:\n\t%#v", actual, expected)
+	}
+}
+
+func TestParamRendererUntypedFlag(t *testing.T) {
+	r := ParamRenderer{}
+	params := []StepParam{
+		{ID: "1", Name: "--mode", Value: "fast", Type: "Flag"},
+		{ID: "2", Name: "--name-only", Value: ""},
+		{ID: "3", Name: "", Value: "value-only"},
+	}
+	actual := r.Render(params)
+	expected := []string{"--mode", "fast", "--name-only", "value-only"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Render() returned:\n\t%#v\ninstead of:\n\t%#v", actual, expected)
+	}
+}
+
+func TestArgumentsWithTypedParams(t *testing.T) {
+	s := _inittests(t, false)
+	s.Steps[0].Config.Params = typedParams()
+	actual := s.Steps[0].Arguments()
+	expected := []string{
+		"/usr/local2/bin/QATestTool.sh",
+		"--verbose",
+		"--level", "3",
+		"--optional",
+		"--prefix=out",
+		"-i", "a.txt", "b.txt",
+		"positional",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Arguments() returned:\n\t%#v\ninstead of:\n\t%#v", actual, expected)
+	}
+
+	actual = s.Steps[0].ArgumentsWith(&ParamRenderer{RepeatNames: true})
+	expected = []string{
+		"/usr/local2/bin/QATestTool.sh",
+		"--verbose",
+		"--level", "3",
+		"--optional",
+		"--prefix=out",
+		"-i", "a.txt", "-i", "b.txt",
+		"positional",
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("ArgumentsWith() returned:\n\t%#v\ninstead of:\n\t%#v", actual, expected)
+	}
+	_inittests(t, false)
+}
+
+func TestEnvironmentWithParams(t *testing.T) {
+	s := _inittests(t, false)
+	s.Steps[0].Config.Params = append(s.Steps[0].Config.Params,
+		StepParam{Name: "TOOL_HOME", Value: "/opt/tool", Type: "EnvironmentVariable"},
+		StepParam{Name: "food", Value: "apple", Type: "EnvironmentVariable"},
+	)
+	actual := s.Steps[0].EnvironmentWithParams()
+	expected := StepEnvironment{"foo": "bar", "food": "apple", "TOOL_HOME": "/opt/tool"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("EnvironmentWithParams() returned %#v instead of %#v", actual, expected)
+	}
+	if s.Steps[0].Environment["food"] != "banana" {
+		t.Error("EnvironmentWithParams() modified the step's environment")
+	}
+	if len(s.Steps[0].EnvOptions()) != 3 {
+		t.Errorf("EnvOptions() returned %#v", s.Steps[0].EnvOptions())
+	}
+	_inittests(t, false)
+}
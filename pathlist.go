@@ -0,0 +1,182 @@
+package model
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// The version of the path list formats written by this package.
+const PathListVersion = 1
+
+// The media types that appear in the header line of each kind of path list. The
+// input path list uses the media type of the DE's HT path lists, which are
+// written with one path per line and no quoting, so it's read line by line
+// rather than as CSV; the ticket lists are CSV.
+const (
+	InputPathListType    = "application/vnd.de.path-list+csv"
+	InputTicketsListType = "application/vnd.de.tickets-path-list+csv"
+	OutputTicketListType = "application/vnd.de.output-ticket-list+csv"
+)
+
+// PathTicket associates an iRODS path with the ticket used to access it.
+type PathTicket struct {
+	Path   string
+	Ticket string
+}
+
+// pathListHeader returns the header line for a path list of the given type.
+func pathListHeader(mediaType string) string {
+	return fmt.Sprintf("# %s; version=%d", mediaType, PathListVersion)
+}
+
+// parsePathListHeader checks that the header line names the expected media type
+// and a version that can be read.
+func parsePathListHeader(line, mediaType string) error {
+	line = strings.TrimSpace(strings.TrimPrefix(line, "#"))
+	parts := strings.Split(line, ";")
+	if strings.TrimSpace(parts[0]) != mediaType {
+		return fmt.Errorf("path list type %q is not %q", strings.TrimSpace(parts[0]), mediaType)
+	}
+	version := 0
+	for _, p := range parts[1:] {
+		p = strings.TrimSpace(p)
+		if strings.HasPrefix(p, "version=") {
+			if _, err := fmt.Sscanf(p, "version=%d", &version); err != nil {
+				return fmt.Errorf("invalid path list version %q", p)
+			}
+		}
+	}
+	if version < 1 || version > PathListVersion {
+		return fmt.Errorf("unsupported path list version %d", version)
+	}
+	return nil
+}
+
+// writePathTickets writes a header followed by one CSV record per path.
+func writePathTickets(w io.Writer, mediaType string, records []PathTicket) error {
+	if _, err := fmt.Fprintln(w, pathListHeader(mediaType)); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(w)
+	for _, r := range records {
+		if err := cw.Write([]string{r.Path, r.Ticket}); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// readPathTickets reads a path list with tickets written by writePathTickets.
+func readPathTickets(r io.Reader, mediaType string) ([]PathTicket, error) {
+	br := bufio.NewReader(r)
+	header, err := br.ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	if err = parsePathListHeader(header, mediaType); err != nil {
+		return nil, err
+	}
+	cr := csv.NewReader(br)
+	cr.FieldsPerRecord = 2
+	var records []PathTicket
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, PathTicket{Path: rec[0], Ticket: rec[1]})
+	}
+	return records, nil
+}
+
+// WriteInputPathList writes the list of inputs that don't have tickets to w,
+// one iRODS path per line after the header line.
+func (job *Job) WriteInputPathList(w io.Writer) error {
+	if _, err := fmt.Fprintln(w, pathListHeader(InputPathListType)); err != nil {
+		return err
+	}
+	for _, input := range job.FilterInputsWithoutTickets() {
+		if input.Value == "" {
+			continue
+		}
+		if _, err := fmt.Fprintln(w, input.IRODSPath()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadInputPathList reads a list of paths written by WriteInputPathList. Only the
+// line terminators are removed, so paths with leading or trailing spaces are
+// read back unchanged. Empty lines are skipped.
+func ReadInputPathList(r io.Reader) ([]string, error) {
+	scanner := bufio.NewScanner(r)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+		return nil, errors.New("path list is empty")
+	}
+	if err := parsePathListHeader(scanner.Text(), InputPathListType); err != nil {
+		return nil, err
+	}
+	var paths []string
+	for scanner.Scan() {
+		if line := strings.TrimSuffix(scanner.Text(), "\r"); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths, scanner.Err()
+}
+
+// WriteInputTicketList writes the inputs that have tickets to w as CSV records
+// containing the iRODS path and the ticket, after the header line.
+func (job *Job) WriteInputTicketList(w io.Writer) error {
+	var records []PathTicket
+	for _, input := range job.FilterInputsWithTickets() {
+		if input.Value == "" {
+			continue
+		}
+		records = append(records, PathTicket{Path: input.IRODSPath(), Ticket: input.Ticket})
+	}
+	return writePathTickets(w, InputTicketsListType, records)
+}
+
+// ReadInputTicketList reads a list of paths and tickets written by
+// WriteInputTicketList.
+func ReadInputTicketList(r io.Reader) ([]PathTicket, error) {
+	return readPathTickets(r, InputTicketsListType)
+}
+
+// WriteOutputTicketList writes the output directory and its write ticket to w
+// as a CSV record after the header line. An error is returned if the job
+// doesn't have an output directory ticket.
+func (job *Job) WriteOutputTicketList(w io.Writer) error {
+	if job.OutputDirTicket == "" {
+		return errors.New("the job does not have an output directory ticket")
+	}
+	return writePathTickets(w, OutputTicketListType, []PathTicket{
+		{Path: job.OutputDirectory(), Ticket: job.OutputDirTicket},
+	})
+}
+
+// ReadOutputTicketList reads the output directory and ticket written by
+// WriteOutputTicketList.
+func ReadOutputTicketList(r io.Reader) (PathTicket, error) {
+	records, err := readPathTickets(r, OutputTicketListType)
+	if err != nil {
+		return PathTicket{}, err
+	}
+	if len(records) != 1 {
+		return PathTicket{}, fmt.Errorf("output ticket list contains %d records instead of 1", len(records))
+	}
+	return records[0], nil
+}
@@ -0,0 +1,130 @@
+package model
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestWriteInputPathList(t *testing.T) {
+	s := _inittests(t, false)
+	s.Steps[0].Config.Inputs[1].Ticket = "ticket1"
+	var buf bytes.Buffer
+	if err := s.WriteInputPathList(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# application/vnd.de.path-list+csv; version=1
+/iplant/home/wregglej/Acer-tree.txt
+/iplant/home/wregglej/input.2
+/iplant/home/wregglej/input.3
+/iplant/home/wregglej/input.4
+/iplant/home/wregglej/input.5
+/iplant/home/wregglej/input.6
+`
+	if buf.String() != expected {
+		t.Errorf("WriteInputPathList() wrote:\n%s\ninstead of:\n%s", buf.String(), expected)
+	}
+
+	paths, err := ReadInputPathList(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) != 6 || paths[0] != "/iplant/home/wregglej/Acer-tree.txt" {
+		t.Errorf("ReadInputPathList() returned %#v", paths)
+	}
+	_inittests(t, false)
+}
+
+func TestReadInputPathListKeepsSpaces(t *testing.T) {
+	list := "# application/vnd.de.path-list+csv; version=1\r\n/iplant/home/a b \r\n\r\n /iplant/home/c\n"
+	paths, err := ReadInputPathList(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/iplant/home/a b ", " /iplant/home/c"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("ReadInputPathList() returned %#v instead of %#v", paths, expected)
+	}
+}
+
+func TestReadHTPathList(t *testing.T) {
+	// A path list as the DE writes it to the data store for HT analyses.
+	list := "# application/vnd.de.path-list+csv; version=1\n/iplant/home/ipctest/batch/a.fq\n/iplant/home/ipctest/batch/b.fq\n"
+	paths, err := ReadInputPathList(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"/iplant/home/ipctest/batch/a.fq", "/iplant/home/ipctest/batch/b.fq"}
+	if !reflect.DeepEqual(paths, expected) {
+		t.Errorf("ReadInputPathList() returned %#v instead of %#v", paths, expected)
+	}
+}
+
+func TestReadInputPathListErrors(t *testing.T) {
+	lists := []string{
+		"",
+		"/iplant/home/wregglej/Acer-tree.txt\n",
+		"# application/vnd.de.path-list+csv; version=2\n/foo\n",
+		"# application/vnd.de.tickets-path-list+csv; version=1\n/foo\n",
+	}
+	for _, l := range lists {
+		if _, err := ReadInputPathList(strings.NewReader(l)); err == nil {
+			t.Errorf("ReadInputPathList(%q) did not return an error", l)
+		}
+	}
+}
+
+func TestWriteInputTicketList(t *testing.T) {
+	s := _inittests(t, false)
+	s.Steps[0].Config.Inputs[0].Ticket = "ticket0"
+	s.Steps[0].Config.Inputs[1].Value = "/iplant/home/wregglej/input, with a comma"
+	s.Steps[0].Config.Inputs[1].Ticket = "ticket1"
+	var buf bytes.Buffer
+	if err := s.WriteInputTicketList(&buf); err != nil {
+		t.Fatal(err)
+	}
+	expected := `# application/vnd.de.tickets-path-list+csv; version=1
+/iplant/home/wregglej/Acer-tree.txt,ticket0
+"/iplant/home/wregglej/input, with a comma",ticket1
+`
+	if buf.String() != expected {
+		t.Errorf("WriteInputTicketList() wrote:\n%s\ninstead of:\n%s", buf.String(), expected)
+	}
+
+	actual, err := ReadInputTicketList(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expectedRecords := []PathTicket{
+		{Path: "/iplant/home/wregglej/Acer-tree.txt", Ticket: "ticket0"},
+		{Path: "/iplant/home/wregglej/input, with a comma", Ticket: "ticket1"},
+	}
+	if !reflect.DeepEqual(actual, expectedRecords) {
+		t.Errorf("ReadInputTicketList() returned %#v instead of %#v", actual, expectedRecords)
+	}
+	_inittests(t, false)
+}
+
+func TestWriteOutputTicketList(t *testing.T) {
+	s := _inittests(t, false)
+	var buf bytes.Buffer
+	if err := s.WriteOutputTicketList(&buf); err == nil {
+		t.Error("WriteOutputTicketList() did not return an error for a job without a ticket")
+	}
+
+	s.OutputDirTicket = "output-ticket"
+	buf.Reset()
+	if err := s.WriteOutputTicketList(&buf); err != nil {
+		t.Fatal(err)
+	}
+	actual, err := ReadOutputTicketList(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := PathTicket{Path: s.OutputDirectory(), Ticket: "output-ticket"}
+	if actual != expected {
+		t.Errorf("ReadOutputTicketList() returned %#v instead of %#v", actual, expected)
+	}
+	_inittests(t, false)
+}
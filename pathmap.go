@@ -0,0 +1,160 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// PathMapping describes where an input or output lives in iRODS, on the host
+// that runs the job and inside the step's container.
+type PathMapping struct {
+	StepIndex     int    // The index of the step in Job.Steps.
+	Name          string // The input or output's name.
+	IsInput       bool
+	IsCollection  bool
+	IRODSPath     string // The location in the data store.
+	LocalPath     string // The location relative to the job's staging directory on the host.
+	HostPath      string // The location on the host, or empty if it isn't known.
+	ContainerPath string // The location inside the step's container.
+}
+
+// PathCollision describes inputs that would be downloaded to the same local
+// path and overwrite each other.
+type PathCollision struct {
+	LocalPath string
+	Mappings  []PathMapping
+}
+
+// String returns a human readable description of the collision.
+func (c PathCollision) String() string {
+	var paths []string
+	for _, m := range c.Mappings {
+		paths = append(paths, m.IRODSPath)
+	}
+	return fmt.Sprintf("%s would be written by %s", c.LocalPath, strings.Join(paths, ", "))
+}
+
+// containerPath returns the location of the local path inside the container.
+// Relative paths are inside the container's working directory.
+func (s *Step) containerPath(localPath string) string {
+	if path.IsAbs(localPath) {
+		return localPath
+	}
+	return path.Join(s.Component.Container.WorkingDirectory(), localPath)
+}
+
+// hostPath returns the host location of a path inside the container, checking
+// the container's volumes first and falling back to the staging directory.
+// An empty string is returned if the location isn't known.
+func (s *Step) hostPath(containerPath, hostStagingDir string) string {
+	var best Volume
+	for _, v := range s.Component.Container.Volumes {
+		if v.HostPath == "" {
+			continue
+		}
+		cp := path.Clean(v.ContainerPath)
+		if (containerPath == cp || strings.HasPrefix(containerPath, cp+"/")) && len(cp) > len(best.ContainerPath) {
+			best = Volume{HostPath: v.HostPath, ContainerPath: cp}
+		}
+	}
+	if best.ContainerPath != "" {
+		return path.Join(best.HostPath, strings.TrimPrefix(containerPath, best.ContainerPath))
+	}
+
+	workingDir := path.Clean(s.Component.Container.WorkingDirectory())
+	if hostStagingDir != "" && (containerPath == workingDir || strings.HasPrefix(containerPath, workingDir+"/")) {
+		return path.Join(hostStagingDir, strings.TrimPrefix(containerPath, workingDir))
+	}
+	return ""
+}
+
+// PathMappings returns the mapping for every input and output in the job, in
+// step order with each step's inputs before its outputs. Inputs without a value
+// are skipped. hostStagingDir is the directory on the host that's mounted as the
+// container's working directory. If it's empty, HostPath is only set for paths
+// inside one of the container's volumes. Outputs outside of the working
+// directory aren't uploaded, so their IRODSPath is empty.
+func (job *Job) PathMappings(hostStagingDir string) []PathMapping {
+	var mappings []PathMapping
+	for idx, step := range job.Steps {
+		for _, input := range step.Config.Inputs {
+			if input.Value == "" {
+				continue
+			}
+			local := input.Source()
+			cp := step.containerPath(local)
+			mappings = append(mappings, PathMapping{
+				StepIndex:     idx,
+				Name:          input.Name,
+				IsInput:       true,
+				IsCollection:  input.ParsedMultiplicity().IsCollection(),
+				IRODSPath:     input.IRODSPath(),
+				LocalPath:     local,
+				HostPath:      step.hostPath(path.Clean(cp), hostStagingDir),
+				ContainerPath: cp,
+			})
+		}
+		for _, output := range step.Config.Outputs {
+			collection := output.ParsedMultiplicity().IsCollection()
+			local := output.Name
+			cp := step.containerPath(local)
+			if collection && !strings.HasSuffix(cp, "/") {
+				cp += "/"
+			}
+			var irodsPath string
+			workingDir := path.Clean(step.Component.Container.WorkingDirectory()) + "/"
+			if strings.HasPrefix(cp, workingDir) {
+				irodsPath = path.Join(job.OutputDirectory(), strings.TrimPrefix(path.Clean(cp), workingDir))
+			}
+			mappings = append(mappings, PathMapping{
+				StepIndex:     idx,
+				Name:          output.Name,
+				IsCollection:  collection,
+				IRODSPath:     irodsPath,
+				LocalPath:     local,
+				HostPath:      step.hostPath(path.Clean(cp), hostStagingDir),
+				ContainerPath: cp,
+			})
+		}
+	}
+	return mappings
+}
+
+// InputPathCollisions returns the local paths that more than one input would be
+// downloaded to. The inputs of every step are downloaded into the same staging
+// directory, so inputs of different steps can collide even if the steps'
+// containers use different working directories. Inputs with the same iRODS path
+// don't collide, since they refer to the same file.
+func (job *Job) InputPathCollisions() []PathCollision {
+	var collisions []PathCollision
+	byLocal := make(map[string][]PathMapping)
+	var order []string
+	for _, m := range job.PathMappings("") {
+		if !m.IsInput {
+			continue
+		}
+		key := path.Clean(m.LocalPath)
+		existing := byLocal[key]
+		duplicate := false
+		for _, e := range existing {
+			duplicate = duplicate || e.IRODSPath == m.IRODSPath
+		}
+		if duplicate {
+			continue
+		}
+		if len(existing) == 0 {
+			order = append(order, key)
+		}
+		byLocal[key] = append(existing, m)
+	}
+	for _, key := range order {
+		if len(byLocal[key]) > 1 {
+			collisions = append(collisions, PathCollision{
+				LocalPath: byLocal[key][0].LocalPath,
+				Mappings:  byLocal[key],
+			})
+		}
+	}
+	return collisions
+}
@@ -0,0 +1,104 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPathMappings(t *testing.T) {
+	s := _inittests(t, false)
+	s.Steps[0].Config.Inputs = s.Steps[0].Config.Inputs[:1]
+	s.Steps[0].Config.Outputs = append(s.Steps[0].Config.Outputs, StepOutput{
+		Multiplicity: "single",
+		Name:         "/container/path1/result.txt",
+	})
+	actual := s.PathMappings("/scratch/job")
+	outputDir := s.OutputDirectory()
+	expected := []PathMapping{
+		{
+			StepIndex:     0,
+			Name:          "Acer-tree.txt",
+			IsInput:       true,
+			IRODSPath:     "/iplant/home/wregglej/Acer-tree.txt",
+			LocalPath:     "Acer-tree.txt",
+			HostPath:      "/scratch/job/Acer-tree.txt",
+			ContainerPath: "/work/Acer-tree.txt",
+		},
+		{
+			StepIndex:     0,
+			Name:          "wc_out.txt",
+			IRODSPath:     outputDir + "/wc_out.txt",
+			LocalPath:     "wc_out.txt",
+			HostPath:      "/scratch/job/wc_out.txt",
+			ContainerPath: "/work/wc_out.txt",
+		},
+		{
+			StepIndex:     0,
+			Name:          "logs",
+			IsCollection:  true,
+			IRODSPath:     outputDir + "/logs",
+			LocalPath:     "logs",
+			HostPath:      "/scratch/job/logs",
+			ContainerPath: "/work/logs/",
+		},
+		{
+			StepIndex:     0,
+			Name:          "/container/path1/result.txt",
+			LocalPath:     "/container/path1/result.txt",
+			HostPath:      "/host/path1/result.txt",
+			ContainerPath: "/container/path1/result.txt",
+		},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("PathMappings() returned:\n\t%#v\ninstead of:\n\t%#v", actual, expected)
+	}
+
+	actual = s.PathMappings("")
+	if actual[0].HostPath != "" {
+		t.Errorf("HostPath was '%s' instead of an empty string", actual[0].HostPath)
+	}
+	if actual[3].HostPath != "/host/path1/result.txt" {
+		t.Errorf("HostPath was '%s' instead of '/host/path1/result.txt'", actual[3].HostPath)
+	}
+	_inittests(t, false)
+}
+
+func TestInputPathCollisions(t *testing.T) {
+	s := _inittests(t, false)
+	if collisions := s.InputPathCollisions(); len(collisions) != 0 {
+		t.Errorf("InputPathCollisions() returned %v instead of no collisions", collisions)
+	}
+
+	s.Steps[0].Config.Inputs[1].Value = "/iplant/home/other/Acer-tree.txt"
+	s.Steps[0].Config.Inputs[2].Value = "/iplant/home/wregglej/Acer-tree.txt"
+	collisions := s.InputPathCollisions()
+	if len(collisions) != 1 {
+		t.Fatalf("InputPathCollisions() returned %d collisions instead of 1", len(collisions))
+	}
+	if collisions[0].LocalPath != "Acer-tree.txt" {
+		t.Errorf("LocalPath was '%s' instead of 'Acer-tree.txt'", collisions[0].LocalPath)
+	}
+	expected := "Acer-tree.txt would be written by /iplant/home/wregglej/Acer-tree.txt, /iplant/home/other/Acer-tree.txt"
+	if collisions[0].String() != expected {
+		t.Errorf("String() returned '%s' instead of '%s'", collisions[0].String(), expected)
+	}
+	_inittests(t, false)
+}
+
+func TestInputPathCollisionsAcrossSteps(t *testing.T) {
+	s := _inittests(t, false)
+	step := s.Steps[0].deepCopy()
+	step.Component.Container.WorkingDir = "/other-work"
+	step.Config.Inputs = step.Config.Inputs[:1]
+	step.Config.Inputs[0].Value = "/iplant/home/other/Acer-tree.txt"
+	s.Steps = append(s.Steps, step)
+
+	collisions := s.InputPathCollisions()
+	if len(collisions) != 1 {
+		t.Fatalf("InputPathCollisions() returned %d collisions instead of 1", len(collisions))
+	}
+	if collisions[0].LocalPath != "Acer-tree.txt" {
+		t.Errorf("LocalPath was '%s' instead of 'Acer-tree.txt'", collisions[0].LocalPath)
+	}
+	_inittests(t, false)
+}
@@ -0,0 +1,234 @@
+package model
+
+import (
+	"fmt"
+	"path"
+)
+
+// PolicyRule identifies one of the checks performed by an ImagePolicy.
+type PolicyRule string
+
+// The rules that an ImagePolicy can report violations for.
+const (
+	RuleInvalidReference PolicyRule = "invalid-reference"
+	RuleRegistry         PolicyRule = "registry"
+	RuleRepository       PolicyRule = "repository"
+	RuleForbiddenTag     PolicyRule = "forbidden-tag"
+	RuleDigest           PolicyRule = "digest"
+	RuleRestricted       PolicyRule = "restricted"
+)
+
+// PolicyException waives some of a policy's rules for the members of a group.
+type PolicyException struct {
+	// The group that the exception applies to, as it appears in Job.UserGroups.
+	Group string `json:"group"`
+
+	// The rules that are waived. All rules are waived if this is empty.
+	Rules []PolicyRule `json:"rules"`
+
+	// Repository patterns that the exception is limited to, in the same format
+	// as ImagePolicy.AllowedRepositories. The exception applies to every image
+	// if this is empty.
+	Repositories []string `json:"repositories"`
+}
+
+// ImagePolicy describes the container images that jobs are allowed to use.
+// Patterns are matched with path.Match, so "harbor.cyverse.org/de/*" matches
+// every repository in the de project.
+type ImagePolicy struct {
+	// Registry host patterns that images may be pulled from. Any registry is
+	// allowed if this is empty.
+	AllowedRegistries []string `json:"allowed_registries"`
+
+	// Patterns matched against "<registry>/<repository>". Any repository is
+	// allowed if this is empty.
+	AllowedRepositories []string `json:"allowed_repositories"`
+
+	// Tags that images may not use, e.g. latest. An image that's pinned to a
+	// digest may use a forbidden tag.
+	ForbiddenTags []string `json:"forbidden_tags"`
+
+	// If true, every image must be pinned to a digest.
+	RequireDigest bool `json:"require_digest"`
+
+	// Groups whose members may run restricted components. Restricted
+	// components aren't checked if this is empty.
+	RestrictedGroups []string `json:"restricted_groups"`
+
+	Exceptions []PolicyException `json:"exceptions"`
+}
+
+// PolicyViolation describes an image that breaks one of a policy's rules.
+type PolicyViolation struct {
+	Rule        PolicyRule
+	Image       string // The image reference as it appears in the job.
+	Source      string // Describes where the image appears in the job.
+	Explanation string
+	WaivedBy    string // The group whose exception waived the violation, if any.
+}
+
+// Waived returns true if the violation was waived by an exception.
+func (v PolicyViolation) Waived() bool {
+	return v.WaivedBy != ""
+}
+
+// String returns a human readable description of the violation.
+func (v PolicyViolation) String() string {
+	s := fmt.Sprintf("%s: %s (%s)", v.Source, v.Explanation, v.Rule)
+	if v.Waived() {
+		s = fmt.Sprintf("%s, waived for %s", s, v.WaivedBy)
+	}
+	return s
+}
+
+// PolicyResult contains every violation found when evaluating a job against an
+// ImagePolicy, including the ones that were waived.
+type PolicyResult struct {
+	Violations []PolicyViolation
+}
+
+// Allowed returns true if every violation was waived.
+func (r PolicyResult) Allowed() bool {
+	return len(r.Unwaived()) == 0
+}
+
+// Unwaived returns the violations that weren't waived by an exception.
+func (r PolicyResult) Unwaived() []PolicyViolation {
+	var retval []PolicyViolation
+	for _, v := range r.Violations {
+		if !v.Waived() {
+			retval = append(retval, v)
+		}
+	}
+	return retval
+}
+
+// matchesAny returns true if s matches at least one of the patterns.
+func matchesAny(patterns []string, s string) bool {
+	for _, p := range patterns {
+		if matched, err := path.Match(p, s); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
+
+// contains returns true if s is in the list.
+func contains(list []string, s string) bool {
+	for _, l := range list {
+		if l == s {
+			return true
+		}
+	}
+	return false
+}
+
+// policyImage is an image to be checked along with where it came from.
+type policyImage struct {
+	name       string
+	tag        string
+	source     string
+	restricted bool
+}
+
+// check returns the rules that the image breaks along with an explanation for
+// each of them.
+func (p *ImagePolicy) check(img policyImage, userGroups []string) []PolicyViolation {
+	var violations []PolicyViolation
+	display := img.name
+	if img.tag != "" {
+		display = fmt.Sprintf("%s:%s", img.name, img.tag)
+	}
+	add := func(rule PolicyRule, ref string, format string, args ...interface{}) {
+		violations = append(violations, PolicyViolation{
+			Rule:        rule,
+			Image:       display,
+			Source:      img.source,
+			Explanation: fmt.Sprintf(format, args...),
+			WaivedBy:    p.waiver(rule, ref, userGroups),
+		})
+	}
+
+	ref, err := (&ContainerImage{Name: img.name, Tag: img.tag}).Reference()
+	if err != nil {
+		add(RuleInvalidReference, "", "the image reference could not be parsed: %s", err)
+		return violations
+	}
+	repo := ref.Name()
+
+	if len(p.AllowedRegistries) > 0 && !matchesAny(p.AllowedRegistries, ref.Registry) {
+		add(RuleRegistry, repo, "registry %s is not in the list of allowed registries", ref.Registry)
+	}
+	if len(p.AllowedRepositories) > 0 && !matchesAny(p.AllowedRepositories, repo) {
+		add(RuleRepository, repo, "repository %s is not in the list of allowed repositories", repo)
+	}
+	if !ref.IsPinned() && contains(p.ForbiddenTags, ref.Tag) {
+		add(RuleForbiddenTag, repo, "tag %s is forbidden unless the image is pinned to a digest", ref.Tag)
+	}
+	if p.RequireDigest && !ref.IsPinned() {
+		add(RuleDigest, repo, "the image is not pinned to a digest")
+	}
+	if img.restricted && len(p.RestrictedGroups) > 0 && !p.inRestrictedGroup(userGroups) {
+		add(RuleRestricted, repo, "the component is restricted and the user is not in an authorized group")
+	}
+	return violations
+}
+
+// inRestrictedGroup returns true if one of the user's groups may run restricted
+// components.
+func (p *ImagePolicy) inRestrictedGroup(userGroups []string) bool {
+	for _, g := range userGroups {
+		if contains(p.RestrictedGroups, g) {
+			return true
+		}
+	}
+	return false
+}
+
+// waiver returns the first of the user's groups that has an exception covering
+// the rule for the repository, or an empty string if there isn't one.
+func (p *ImagePolicy) waiver(rule PolicyRule, repo string, userGroups []string) string {
+	for _, e := range p.Exceptions {
+		if !contains(userGroups, e.Group) {
+			continue
+		}
+		if len(e.Rules) > 0 {
+			found := false
+			for _, r := range e.Rules {
+				found = found || r == rule
+			}
+			if !found {
+				continue
+			}
+		}
+		if len(e.Repositories) > 0 && !matchesAny(e.Repositories, repo) {
+			continue
+		}
+		return e.Group
+	}
+	return ""
+}
+
+// Evaluate checks every container image and data container in the job against
+// the policy. Exceptions are matched against job.UserGroups.
+func (p *ImagePolicy) Evaluate(job *Job) PolicyResult {
+	var result PolicyResult
+	for i, ci := range job.ContainerImages() {
+		img := policyImage{
+			name:       ci.Name,
+			tag:        ci.Tag,
+			source:     fmt.Sprintf("image for step %d", i),
+			restricted: job.Steps[i].Component.Restricted,
+		}
+		result.Violations = append(result.Violations, p.check(img, job.UserGroups)...)
+	}
+	for _, dc := range job.DataContainers() {
+		img := policyImage{
+			name:   dc.Name,
+			tag:    dc.Tag,
+			source: fmt.Sprintf("data container %s", dc.Name),
+		}
+		result.Violations = append(result.Violations, p.check(img, job.UserGroups)...)
+	}
+	return result
+}
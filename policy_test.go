@@ -0,0 +1,110 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func violationRules(violations []PolicyViolation) []PolicyRule {
+	var rules []PolicyRule
+	for _, v := range violations {
+		rules = append(rules, v.Rule)
+	}
+	return rules
+}
+
+func TestImagePolicyEvaluate(t *testing.T) {
+	s := _inittests(t, false)
+	policy := ImagePolicy{
+		AllowedRegistries:   []string{"gims.iplantcollaborative.org:5000", "docker.io"},
+		AllowedRepositories: []string{"gims.iplantcollaborative.org:5000/*", "docker.io/library/vf-name1"},
+		ForbiddenTags:       []string{"latest"},
+	}
+	result := policy.Evaluate(s)
+	if result.Allowed() {
+		t.Error("Allowed() returned true")
+	}
+	actual := violationRules(result.Violations)
+	expected := []PolicyRule{RuleForbiddenTag, RuleRepository}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Evaluate() returned violations %#v instead of %#v", actual, expected)
+	}
+	if result.Violations[0].Source != "image for step 0" {
+		t.Errorf("The violation source was '%s' instead of 'image for step 0'", result.Violations[0].Source)
+	}
+	if result.Violations[1].Image != "vf-name2:vf-tag2" {
+		t.Errorf("The violation image was '%s' instead of 'vf-name2:vf-tag2'", result.Violations[1].Image)
+	}
+
+	s.Steps[0].Component.Container.Image.Name = "gims.iplantcollaborative.org:5000/backwards-compat@" + testDigest
+	s.Steps[0].Component.Container.VolumesFrom = s.Steps[0].Component.Container.VolumesFrom[:1]
+	result = policy.Evaluate(s)
+	if !result.Allowed() {
+		t.Errorf("Allowed() returned false: %v", result.Violations)
+	}
+	_inittests(t, false)
+}
+
+func TestImagePolicyRequireDigest(t *testing.T) {
+	s := _inittests(t, false)
+	s.Steps[0].Component.Container.VolumesFrom = nil
+	policy := ImagePolicy{RequireDigest: true}
+	result := policy.Evaluate(s)
+	actual := violationRules(result.Violations)
+	expected := []PolicyRule{RuleDigest}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Evaluate() returned violations %#v instead of %#v", actual, expected)
+	}
+	_inittests(t, false)
+}
+
+func TestImagePolicyExceptions(t *testing.T) {
+	s := _inittests(t, false)
+	s.Steps[0].Component.Container.VolumesFrom = nil
+	policy := ImagePolicy{
+		AllowedRegistries: []string{"harbor.cyverse.org"},
+		RequireDigest:     true,
+		Exceptions: []PolicyException{
+			{Group: "groups:bar", Rules: []PolicyRule{RuleDigest}},
+			{Group: "groups:other"},
+		},
+	}
+	result := policy.Evaluate(s)
+	if result.Allowed() {
+		t.Error("Allowed() returned true")
+	}
+	unwaived := violationRules(result.Unwaived())
+	expected := []PolicyRule{RuleRegistry}
+	if !reflect.DeepEqual(unwaived, expected) {
+		t.Errorf("Unwaived() returned %#v instead of %#v", unwaived, expected)
+	}
+	for _, v := range result.Violations {
+		if v.Rule == RuleDigest && v.WaivedBy != "groups:bar" {
+			t.Errorf("The digest violation was waived by '%s' instead of 'groups:bar'", v.WaivedBy)
+		}
+	}
+
+	s.UserGroups = append(s.UserGroups, "groups:other")
+	if result = policy.Evaluate(s); !result.Allowed() {
+		t.Errorf("Allowed() returned false: %v", result.Unwaived())
+	}
+	_inittests(t, false)
+}
+
+func TestImagePolicyRestricted(t *testing.T) {
+	s := _inittests(t, false)
+	s.Steps[0].Component.Container.VolumesFrom = nil
+	s.Steps[0].Component.Restricted = true
+	policy := ImagePolicy{RestrictedGroups: []string{"groups:trusted"}}
+	result := policy.Evaluate(s)
+	actual := violationRules(result.Violations)
+	expected := []PolicyRule{RuleRestricted}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Evaluate() returned violations %#v instead of %#v", actual, expected)
+	}
+	s.UserGroups = []string{"groups:trusted"}
+	if result = policy.Evaluate(s); !result.Allowed() {
+		t.Errorf("Allowed() returned false: %v", result.Violations)
+	}
+	_inittests(t, false)
+}
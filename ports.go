@@ -0,0 +1,156 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+)
+
+const maxPort = 65535
+
+// PortConflict describes a host port that more than one port mapping in a job
+// tries to bind to.
+type PortConflict struct {
+	HostPort int
+	Mappings []PortMapping // Every mapping that binds to HostPort, in step order.
+}
+
+// String returns a human readable description of the conflict.
+func (c PortConflict) String() string {
+	return fmt.Sprintf("host port %d is bound by %d port mappings", c.HostPort, len(c.Mappings))
+}
+
+// PortMapping associates a port mapping with the step that it belongs to.
+type PortMapping struct {
+	StepIndex     int    // The index of the step in Job.Steps.
+	ContainerName string // The name of the step's container.
+	HostPort      int
+	ContainerPort int
+}
+
+// hostBindings returns the port mappings in the job that bind to a host port,
+// keyed by that port. Mappings with a host port of 0 haven't been assigned a
+// port yet, so they're skipped.
+func (job *Job) hostBindings() (map[int][]PortMapping, []int) {
+	bindings := make(map[int][]PortMapping)
+	var order []int
+	for idx, step := range job.Steps {
+		for _, p := range step.Component.Container.Ports {
+			if !p.BindToHost || p.HostPort == 0 {
+				continue
+			}
+			if _, ok := bindings[p.HostPort]; !ok {
+				order = append(order, p.HostPort)
+			}
+			bindings[p.HostPort] = append(bindings[p.HostPort], PortMapping{
+				StepIndex:     idx,
+				ContainerName: step.Component.Container.Name,
+				HostPort:      p.HostPort,
+				ContainerPort: p.ContainerPort,
+			})
+		}
+	}
+	return bindings, order
+}
+
+// PortConflicts returns the host ports that are bound to by more than one port
+// mapping across all of the steps in the job. Only mappings with BindToHost set
+// are considered. Conflicts are returned in the order that the host ports first
+// appear in the job.
+func (job *Job) PortConflicts() []PortConflict {
+	var conflicts []PortConflict
+	bindings, order := job.hostBindings()
+	for _, port := range order {
+		if len(bindings[port]) > 1 {
+			conflicts = append(conflicts, PortConflict{HostPort: port, Mappings: bindings[port]})
+		}
+	}
+	return conflicts
+}
+
+// ValidatePorts returns an error describing every port mapping that's out of
+// range and every host port conflict in the job.
+func (job *Job) ValidatePorts() error {
+	var errs []error
+	for idx, step := range job.Steps {
+		for _, p := range step.Component.Container.Ports {
+			if p.ContainerPort < 1 || p.ContainerPort > maxPort {
+				errs = append(errs, fmt.Errorf("step %d: container port %d is out of range", idx, p.ContainerPort))
+			}
+			if p.HostPort < 0 || p.HostPort > maxPort {
+				errs = append(errs, fmt.Errorf("step %d: host port %d is out of range", idx, p.HostPort))
+			}
+		}
+	}
+	for _, c := range job.PortConflicts() {
+		errs = append(errs, errors.New(c.String()))
+	}
+	return errors.Join(errs...)
+}
+
+// PortAllocator assigns host ports to port mappings that bind to the host but
+// don't specify a host port.
+type PortAllocator struct {
+	Min      int   // The lowest host port that may be assigned.
+	Max      int   // The highest host port that may be assigned.
+	Reserved []int // Host ports that are in use elsewhere and must not be assigned.
+}
+
+// DefaultPortAllocator assigns host ports from the IANA dynamic port range.
+var DefaultPortAllocator = PortAllocator{Min: 49152, Max: 65535}
+
+// Allocate returns a copy of the job in which every port mapping that binds to
+// the host with a HostPort of 0 has been assigned an unused port from the
+// allocator's range, along with the mappings that were assigned. Ports are
+// assigned in step order starting from the bottom of the range, so the result
+// is deterministic. The original job isn't modified. An error is returned if
+// the job already contains port conflicts or the range runs out of ports.
+func (a *PortAllocator) Allocate(job *Job) (*Job, []PortMapping, error) {
+	if a.Min < 1 || a.Max > maxPort || a.Min > a.Max {
+		return nil, nil, fmt.Errorf("invalid host port range %d-%d", a.Min, a.Max)
+	}
+	if conflicts := job.PortConflicts(); len(conflicts) > 0 {
+		return nil, nil, errors.New(conflicts[0].String())
+	}
+
+	used := make(map[int]bool)
+	for _, port := range a.Reserved {
+		used[port] = true
+	}
+	bindings, _ := job.hostBindings()
+	for port := range bindings {
+		used[port] = true
+	}
+
+	allocated := *job
+	allocated.Steps = make([]Step, len(job.Steps))
+	copy(allocated.Steps, job.Steps)
+
+	var assigned []PortMapping
+	next := a.Min
+	for idx, step := range allocated.Steps {
+		ports := make([]Ports, len(step.Component.Container.Ports))
+		copy(ports, step.Component.Container.Ports)
+		for i, p := range ports {
+			if !p.BindToHost || p.HostPort != 0 {
+				continue
+			}
+			for next <= a.Max && used[next] {
+				next++
+			}
+			if next > a.Max {
+				return nil, nil, fmt.Errorf("no free host ports left in range %d-%d", a.Min, a.Max)
+			}
+			used[next] = true
+			ports[i].HostPort = next
+			assigned = append(assigned, PortMapping{
+				StepIndex:     idx,
+				ContainerName: step.Component.Container.Name,
+				HostPort:      next,
+				ContainerPort: p.ContainerPort,
+			})
+		}
+		allocated.Steps[idx].Component.Container.Ports = ports
+	}
+
+	return &allocated, assigned, nil
+}
@@ -0,0 +1,81 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPortConflicts(t *testing.T) {
+	s := _inittests(t, false)
+	if conflicts := s.PortConflicts(); len(conflicts) != 0 {
+		t.Errorf("PortConflicts() returned %v instead of no conflicts", conflicts)
+	}
+	if err := s.ValidatePorts(); err != nil {
+		t.Errorf("ValidatePorts() returned an error: %s", err)
+	}
+
+	second := s.Steps[0]
+	second.Component.Container.Name = "second"
+	second.Component.Container.Ports = []Ports{
+		{ContainerPort: 80, HostPort: 1003, BindToHost: true},
+		{ContainerPort: 81, HostPort: 1001, BindToHost: true},
+	}
+	s.Steps = append(s.Steps, second)
+
+	conflicts := s.PortConflicts()
+	if len(conflicts) != 1 {
+		t.Fatalf("PortConflicts() returned %d conflicts instead of 1", len(conflicts))
+	}
+	expected := PortConflict{
+		HostPort: 1003,
+		Mappings: []PortMapping{
+			{StepIndex: 0, ContainerName: "test-name", HostPort: 1003, ContainerPort: 1002},
+			{StepIndex: 1, ContainerName: "second", HostPort: 1003, ContainerPort: 80},
+		},
+	}
+	if !reflect.DeepEqual(conflicts[0], expected) {
+		t.Errorf("PortConflicts() returned %#v instead of %#v", conflicts[0], expected)
+	}
+	if err := s.ValidatePorts(); err == nil {
+		t.Error("ValidatePorts() did not return an error")
+	}
+	_inittests(t, false)
+}
+
+func TestAllocatePorts(t *testing.T) {
+	s := _inittests(t, false)
+	s.Steps[0].Component.Container.Ports = append(
+		s.Steps[0].Component.Container.Ports,
+		Ports{ContainerPort: 8080, BindToHost: true},
+		Ports{ContainerPort: 8081, BindToHost: false},
+		Ports{ContainerPort: 8082, BindToHost: true},
+	)
+
+	allocator := PortAllocator{Min: 1002, Max: 1010, Reserved: []int{1004}}
+	allocated, assigned, err := allocator.Allocate(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []PortMapping{
+		{StepIndex: 0, ContainerName: "test-name", HostPort: 1002, ContainerPort: 8080},
+		{StepIndex: 0, ContainerName: "test-name", HostPort: 1005, ContainerPort: 8082},
+	}
+	if !reflect.DeepEqual(assigned, expected) {
+		t.Errorf("Allocate() assigned %#v instead of %#v", assigned, expected)
+	}
+
+	ports := allocated.Steps[0].Component.Container.Ports
+	if ports[2].HostPort != 1002 || ports[3].HostPort != 0 || ports[4].HostPort != 1005 {
+		t.Errorf("Allocate() returned unexpected ports: %#v", ports)
+	}
+	if s.Steps[0].Component.Container.Ports[2].HostPort != 0 {
+		t.Error("Allocate() modified the original job")
+	}
+
+	allocator = PortAllocator{Min: 1002, Max: 1003}
+	if _, _, err = allocator.Allocate(s); err == nil {
+		t.Error("Allocate() did not return an error when the range ran out of ports")
+	}
+	_inittests(t, false)
+}
@@ -0,0 +1,118 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// shellSafe matches strings that don't need to be quoted for a POSIX shell.
+var shellSafe = regexp.MustCompile(`^[A-Za-z0-9_@%+=:,./-]+$`)
+
+// shellQuote quotes a string for a POSIX shell. Unlike quote(), the result can
+// be pasted into a terminal and will be passed to the command unchanged.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	if shellSafe.MatchString(s) {
+		return s
+	}
+	return fmt.Sprintf("'%s'", strings.ReplaceAll(s, "'", `'\''`))
+}
+
+// shellJoin quotes each argument and joins them with spaces.
+func shellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// PreviewOptions controls what's included in a command-line preview.
+type PreviewOptions struct {
+	// Renders the step parameters. Defaults to DefaultParamRenderer, which is
+	// what Step.Arguments() uses.
+	Renderer *ParamRenderer
+
+	// Include environment variable assignments before the command.
+	Environment bool
+
+	// Include the stdin, stdout and stderr redirections.
+	Redirections bool
+
+	// The suffix passed to Step.Stdout() and Step.Stderr() for the default log
+	// file names.
+	Suffix string
+}
+
+// renderer returns the renderer to use for the preview.
+func (o *PreviewOptions) renderer() *ParamRenderer {
+	if o.Renderer == nil {
+		return &DefaultParamRenderer
+	}
+	return o.Renderer
+}
+
+// Preview returns the step's command line quoted for a POSIX shell. The
+// arguments come from Step.ArgumentsWith(), so the preview always matches what
+// gets executed.
+func (s *Step) Preview(opts PreviewOptions) string {
+	var parts []string
+	if opts.Environment {
+		env := s.EnvironmentWithParams()
+		var names []string
+		for k := range env {
+			names = append(names, k)
+		}
+		sort.Strings(names)
+		for _, k := range names {
+			parts = append(parts, fmt.Sprintf("%s=%s", k, shellQuote(env[k])))
+		}
+	}
+	if args := s.ArgumentsWith(opts.renderer()); len(args) > 0 {
+		parts = append(parts, shellJoin(args))
+	}
+	if opts.Redirections {
+		if s.StdinPath != "" {
+			parts = append(parts, "<", shellQuote(s.StdinPath))
+		}
+		parts = append(parts, ">", shellQuote(s.Stdout(opts.Suffix)))
+		parts = append(parts, "2>", shellQuote(s.Stderr(opts.Suffix)))
+	}
+	return strings.Join(parts, " ")
+}
+
+// PreviewLines returns the preview of each of the job's steps. The index of
+// the step is used as the suffix for the default log file names unless
+// opts.Suffix is set.
+func (job *Job) PreviewLines(opts PreviewOptions) []string {
+	lines := make([]string, len(job.Steps))
+	for i, step := range job.Steps {
+		stepOpts := opts
+		if stepOpts.Suffix == "" {
+			stepOpts.Suffix = fmt.Sprintf("%d", i)
+		}
+		lines[i] = step.Preview(stepOpts)
+	}
+	return lines
+}
+
+// PreviewScript returns a shell script that runs each of the job's steps in
+// order, stopping at the first one that fails. Each step is preceded by a
+// comment naming its tool and image.
+func (job *Job) PreviewScript(opts PreviewOptions) string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\nset -e\n")
+	for i, line := range job.PreviewLines(opts) {
+		c := job.Steps[i].Component
+		img := c.Container.Image.Name
+		if c.Container.Image.Tag != "" {
+			img = fmt.Sprintf("%s:%s", img, c.Container.Image.Tag)
+		}
+		fmt.Fprintf(&b, "\n# Step %d: %s (%s)\n%s\n", i+1, c.Name, img, line)
+	}
+	return b.String()
+}
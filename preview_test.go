@@ -0,0 +1,92 @@
+package model
+
+import (
+	"testing"
+)
+
+func TestShellQuote(t *testing.T) {
+	values := map[string]string{
+		"":                  `''`,
+		"foo":               "foo",
+		"/path/to/file.txt": "/path/to/file.txt",
+		"--name=value":      "--name=value",
+		"two words":         "'two words'",
+		"it's":              `'it'\''s'`,
+		"$HOME":             "'$HOME'",
+	}
+	for s, expected := range values {
+		if actual := shellQuote(s); actual != expected {
+			t.Errorf("shellQuote(%q) returned %s instead of %s", s, actual, expected)
+		}
+	}
+}
+
+func TestStepPreview(t *testing.T) {
+	s := _inittests(t, false)
+	step := &s.Steps[0]
+	step.Config.Params = []StepParam{
+		{ID: "1", Name: "--title", Value: "my 'first' run", Order: 1, Type: "Text"},
+		{ID: "2", Name: "--verbose", Value: "true", Order: 2, Type: "Flag"},
+		{ID: "3", Name: "MODE", Value: "a b", Order: 3, Type: "EnvironmentVariable"},
+	}
+
+	actual := step.Preview(PreviewOptions{})
+	expected := `/usr/local2/bin/QATestTool.sh --title 'my '\''first'\'' run' --verbose`
+	if actual != expected {
+		t.Errorf("Preview() returned:\n\t%s\ninstead of:\n\t%s", actual, expected)
+	}
+
+	actual = step.Preview(PreviewOptions{Environment: true, Redirections: true, Suffix: "0"})
+	expected = `MODE='a b' foo=bar food=banana /usr/local2/bin/QATestTool.sh --title 'my '\''first'\'' run' --verbose < /path/to/stdin > /path/to/stdout 2> /path/to/stderr`
+	if actual != expected {
+		t.Errorf("Preview() returned:\n\t%s\ninstead of:\n\t%s", actual, expected)
+	}
+
+	step.StdinPath = ""
+	step.StdoutPath = ""
+	step.StderrPath = ""
+	actual = step.Preview(PreviewOptions{Redirections: true, Suffix: "0"})
+	expected = `/usr/local2/bin/QATestTool.sh --title 'my '\''first'\'' run' --verbose > logs/condor-stdout-0 2> logs/condor-stderr-0`
+	if actual != expected {
+		t.Errorf("Preview() returned:\n\t%s\ninstead of:\n\t%s", actual, expected)
+	}
+	_inittests(t, false)
+}
+
+func TestPreviewMatchesArguments(t *testing.T) {
+	s := _inittests(t, false)
+	s.Steps[0].Environment = nil
+	actual := s.Steps[0].Preview(PreviewOptions{})
+	expected := shellJoin(s.Steps[0].Arguments())
+	if actual != expected {
+		t.Errorf("Preview() returned:\n\t%s\ninstead of:\n\t%s", actual, expected)
+	}
+
+	params := PreviewableStepParam(s.Steps[0].Config.Params)
+	expected = "param1 Acer-tree.txt param3 true param0 wc_out.txt param4 four --multi-param2 input.1 input.2 input.3 input.4 input.5 input.6"
+	if params.String() != expected {
+		t.Errorf("String() returned:\n\t%s\ninstead of:\n\t%s", params.String(), expected)
+	}
+	_inittests(t, false)
+}
+
+func TestJobPreviewScript(t *testing.T) {
+	s := _inittests(t, false)
+	s.Steps[0].Config.Params = []StepParam{{Name: "-n", Value: "1", Type: "Integer"}}
+	s.Steps = append(s.Steps, s.Steps[0])
+	s.Steps[1].StdoutPath = ""
+	actual := s.PreviewScript(PreviewOptions{Redirections: true})
+	expected := `#!/bin/sh
+set -e
+
+# Step 1: QATestTool.sh (gims.iplantcollaborative.org:5000/backwards-compat:latest)
+/usr/local2/bin/QATestTool.sh -n 1 < /path/to/stdin > /path/to/stdout 2> /path/to/stderr
+
+# Step 2: QATestTool.sh (gims.iplantcollaborative.org:5000/backwards-compat:latest)
+/usr/local2/bin/QATestTool.sh -n 1 < /path/to/stdin > logs/condor-stdout-1 2> /path/to/stderr
+`
+	if actual != expected {
+		t.Errorf("PreviewScript() returned:\n%s\ninstead of:\n%s", actual, expected)
+	}
+	_inittests(t, false)
+}
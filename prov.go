@@ -0,0 +1,151 @@
+package model
+
+import (
+	"fmt"
+	"time"
+)
+
+// PROVNamespace is the namespace of the DE-specific identifiers and attributes
+// in PROV documents. It's bound to the "de" prefix.
+const PROVNamespace = "https://de.cyverse.org/ns/prov#"
+
+// PROVRecord is an element or relation in a PROV-JSON document, keyed by
+// attribute name.
+type PROVRecord map[string]any
+
+// PROVDocument is a W3C PROV-JSON document. The records in each section are
+// keyed by their identifiers.
+type PROVDocument struct {
+	Prefix            map[string]string     `json:"prefix"`
+	Entity            map[string]PROVRecord `json:"entity,omitempty"`
+	Activity          map[string]PROVRecord `json:"activity,omitempty"`
+	Agent             map[string]PROVRecord `json:"agent,omitempty"`
+	Used              map[string]PROVRecord `json:"used,omitempty"`
+	WasGeneratedBy    map[string]PROVRecord `json:"wasGeneratedBy,omitempty"`
+	WasAssociatedWith map[string]PROVRecord `json:"wasAssociatedWith,omitempty"`
+	ActedOnBehalfOf   map[string]PROVRecord `json:"actedOnBehalfOf,omitempty"`
+	WasAttributedTo   map[string]PROVRecord `json:"wasAttributedTo,omitempty"`
+}
+
+// setIfNotEmpty adds the attribute to the record if the value isn't empty.
+func (r PROVRecord) setIfNotEmpty(attribute, value string) {
+	if value != "" {
+		r[attribute] = value
+	}
+}
+
+// provTime returns a typed PROV-JSON literal for a timestamp, or nil if the time
+// is zero.
+func provTime(t time.Time) any {
+	if t.IsZero() {
+		return nil
+	}
+	return map[string]string{"$": exportTime(t), "type": "xsd:dateTime"}
+}
+
+// PROV describes the job as a W3C PROV-JSON document. The execution is an
+// activity that used the inputs, parameters, tools and container images, and
+// generated the outputs. The submitter is the agent responsible for it, and the
+// app is a software agent acting on the submitter's behalf. Inputs and outputs
+// are identified by their iRODS paths under the irods prefix. The timeline is
+// optional; it fills in the times that the job's Date* fields don't have.
+func (job *Job) PROV(timeline []StatusUpdate, cfg *ExportConfig) (*PROVDocument, error) {
+	r, err := job.exportRecord(timeline, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := &PROVDocument{
+		Prefix: map[string]string{
+			"de":     PROVNamespace,
+			"irods":  cfg.irodsURI(""),
+			"docker": "docker://",
+		},
+		Entity:            make(map[string]PROVRecord),
+		Activity:          make(map[string]PROVRecord),
+		Agent:             make(map[string]PROVRecord),
+		Used:              make(map[string]PROVRecord),
+		WasGeneratedBy:    make(map[string]PROVRecord),
+		WasAssociatedWith: make(map[string]PROVRecord),
+		ActedOnBehalfOf:   make(map[string]PROVRecord),
+		WasAttributedTo:   make(map[string]PROVRecord),
+	}
+
+	executionID := "de:execution-" + job.InvocationID
+	appID := "de:app-" + job.AppID
+	agentID := "de:user-" + job.Submitter
+
+	execution := PROVRecord{"prov:type": "de:Analysis", "prov:label": job.Name}
+	if t := provTime(r.started); t != nil {
+		execution["prov:startTime"] = t
+	}
+	if t := provTime(r.completed); t != nil {
+		execution["prov:endTime"] = t
+	}
+	if t := provTime(r.submitted); t != nil {
+		execution["de:submittedAtTime"] = t
+	}
+	execution.setIfNotEmpty("de:status", r.state)
+	execution.setIfNotEmpty("de:executionTarget", job.ExecutionTarget)
+	doc.Activity[executionID] = execution
+
+	doc.Agent[agentID] = PROVRecord{"prov:type": "prov:Person", "prov:label": job.Submitter}
+	doc.Agent[appID] = PROVRecord{"prov:type": "prov:SoftwareAgent", "prov:label": job.AppName}
+	doc.WasAssociatedWith["_:association-user"] = PROVRecord{"prov:activity": executionID, "prov:agent": agentID}
+	doc.WasAssociatedWith["_:association-app"] = PROVRecord{"prov:activity": executionID, "prov:agent": appID}
+	doc.ActedOnBehalfOf["_:delegation"] = PROVRecord{
+		"prov:delegate":    appID,
+		"prov:responsible": agentID,
+		"prov:activity":    executionID,
+	}
+
+	used := func(entityID, role string) {
+		doc.Used[fmt.Sprintf("_:used-%d", len(doc.Used)+1)] = PROVRecord{
+			"prov:activity": executionID,
+			"prov:entity":   entityID,
+			"prov:role":     role,
+		}
+	}
+
+	for _, f := range r.inputs {
+		id := "irods:" + f.path
+		doc.Entity[id] = PROVRecord{"prov:type": "de:Input", "prov:label": f.name}
+		used(id, "de:input")
+	}
+	for _, p := range r.params {
+		id := "de:" + p.id
+		param := PROVRecord{"prov:type": "de:Parameter", "prov:value": p.param.Value}
+		param.setIfNotEmpty("prov:label", p.param.Name)
+		doc.Entity[id] = param
+		used(id, "de:parameter")
+	}
+	for _, image := range r.images {
+		id := "docker:" + image
+		doc.Entity[id] = PROVRecord{"prov:type": "de:ContainerImage", "prov:label": image}
+		used(id, "de:containerImage")
+	}
+	for _, t := range r.tools {
+		id := "de:" + t.id
+		tool := PROVRecord{
+			"prov:type":  "de:Tool",
+			"prov:label": t.component.Name,
+			"de:image":   "docker:" + t.image,
+		}
+		tool.setIfNotEmpty("de:location", t.component.Location)
+		doc.Entity[id] = tool
+		used(id, "de:tool")
+	}
+
+	generatedAt := provTime(r.completed)
+	for i, f := range r.outputs {
+		id := "irods:" + f.path
+		doc.Entity[id] = PROVRecord{"prov:type": "de:Output", "prov:label": f.name}
+		generation := PROVRecord{"prov:entity": id, "prov:activity": executionID}
+		if generatedAt != nil {
+			generation["prov:time"] = generatedAt
+		}
+		doc.WasGeneratedBy[fmt.Sprintf("_:generation-%d", i+1)] = generation
+		doc.WasAttributedTo[fmt.Sprintf("_:attribution-%d", i+1)] = PROVRecord{"prov:entity": id, "prov:agent": agentID}
+	}
+	return doc, nil
+}
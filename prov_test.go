@@ -0,0 +1,77 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestPROV(t *testing.T) {
+	s := _inittests(t, false)
+	s.DateSubmitted = time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)
+	s.DateCompleted = time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	timeline := []StatusUpdate{
+		{State: StatusRunning, SentOn: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)},
+		{State: StatusFailed, SentOn: time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)},
+	}
+
+	doc, err := s.PROV(timeline, &ExportConfig{IRODSHost: "data.cyverse.org"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.Prefix["irods"] != "irods://data.cyverse.org" {
+		t.Errorf("the irods prefix was %s", doc.Prefix["irods"])
+	}
+
+	execution, ok := doc.Activity["de:execution-"+s.InvocationID]
+	if !ok {
+		t.Fatal("the document has no execution activity")
+	}
+	expectedTimes := map[string]string{
+		"prov:startTime":     "2024-05-01T12:00:00Z",
+		"prov:endTime":       "2024-05-01T13:00:00Z",
+		"de:submittedAtTime": "2024-05-01T11:00:00Z",
+	}
+	for attr, expected := range expectedTimes {
+		v, ok := execution[attr].(map[string]string)
+		if !ok || v["$"] != expected || v["type"] != "xsd:dateTime" {
+			t.Errorf("%s was %#v instead of %s", attr, execution[attr], expected)
+		}
+	}
+	if execution["de:status"] != StatusFailed {
+		t.Errorf("the status was %v", execution["de:status"])
+	}
+
+	if doc.Agent["de:user-"+s.Submitter]["prov:type"] != "prov:Person" {
+		t.Error("the submitter isn't a person")
+	}
+	if doc.Agent["de:app-"+s.AppID]["prov:type"] != "prov:SoftwareAgent" {
+		t.Error("the app isn't a software agent")
+	}
+	if _, ok := doc.Entity["irods:/iplant/home/wregglej/Acer-tree.txt"]; !ok {
+		t.Error("the input entity is missing")
+	}
+	if _, ok := doc.Entity["docker:gims.iplantcollaborative.org:5000/backwards-compat:latest"]; !ok {
+		t.Error("the container image entity is missing")
+	}
+
+	// Every relation must refer to records in the document.
+	for id, u := range doc.Used {
+		if _, ok := doc.Entity[u["prov:entity"].(string)]; !ok {
+			t.Errorf("%s refers to the missing entity %s", id, u["prov:entity"])
+		}
+	}
+	if len(doc.WasGeneratedBy) != len(s.Outputs()) {
+		t.Errorf("%d outputs were generated instead of %d", len(doc.WasGeneratedBy), len(s.Outputs()))
+	}
+	for id, g := range doc.WasGeneratedBy {
+		entity := doc.Entity[g["prov:entity"].(string)]
+		if entity == nil || entity["prov:type"] != "de:Output" {
+			t.Errorf("%s refers to %#v", id, entity)
+		}
+	}
+
+	if _, err := json.Marshal(doc); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,182 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DefaultProvenancePrefix is prepended to the provenance attribute names when
+// ProvenanceConfig.Prefix isn't set.
+const DefaultProvenancePrefix = "ipc-"
+
+// The names of the provenance attributes, without the prefix.
+const (
+	ProvenanceAppName         = "app-name"
+	ProvenanceSubmitter       = "submitter"
+	ProvenanceSubmissionDate  = "submission-date"
+	ProvenanceImage           = "container-image"
+	ProvenanceParametersHash  = "parameters-hash"
+	ProvenanceBatchID         = "batch-id"
+	ProvenanceExecutionTarget = "execution-target"
+)
+
+// DigestResolver returns the content digest of an image, e.g. by asking its
+// registry. It's only called for references that don't already include a
+// digest. An empty digest leaves the reference unpinned.
+type DigestResolver func(ref ImageReference) (string, error)
+
+// ProvenanceConfig contains the settings used to generate provenance AVUs.
+type ProvenanceConfig struct {
+	// Prepended to each attribute name. Defaults to DefaultProvenancePrefix.
+	Prefix string
+
+	// Pins the container image references to digests. Optional.
+	ResolveDigest DigestResolver
+}
+
+// attribute returns the full name of a provenance attribute.
+func (c *ProvenanceConfig) attribute(name string) string {
+	prefix := DefaultProvenancePrefix
+	if c != nil && c.Prefix != "" {
+		prefix = c.Prefix
+	}
+	return prefix + name
+}
+
+// provenanceNames lists the names of the provenance attributes.
+var provenanceNames = []string{
+	ProvenanceAppName,
+	ProvenanceSubmitter,
+	ProvenanceSubmissionDate,
+	ProvenanceImage,
+	ProvenanceParametersHash,
+	ProvenanceBatchID,
+	ProvenanceExecutionTarget,
+}
+
+// provenanceValue makes a job field usable as an AVU value: commas and control
+// characters, which porklock can't pass through, are replaced by spaces, runs of
+// spaces are collapsed, and the result is cut to MaxAVULength bytes.
+func provenanceValue(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if r == ',' || unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, value)
+	value = strings.Join(strings.Fields(value), " ")
+	for len(value) > MaxAVULength {
+		_, size := utf8.DecodeLastRuneInString(value)
+		value = value[:len(value)-size]
+	}
+	return value
+}
+
+// ParametersHash returns the hex-encoded SHA-256 hash of the IDs and values of
+// the parameters of every step, in step order and then parameter order. Jobs
+// that run with the same parameter values get the same hash.
+func (job *Job) ParametersHash() string {
+	var pairs [][]string
+	for _, step := range job.Steps {
+		params := append([]StepParam{}, step.Config.Params...)
+		sort.Stable(ByOrder(params))
+		for _, p := range params {
+			pairs = append(pairs, []string{p.ID, p.Value})
+		}
+	}
+
+	// Encoding the pairs as JSON keeps values that contain separators from
+	// running together.
+	encoded, _ := json.Marshal(pairs)
+	sum := sha256.Sum256(encoded)
+	return hex.EncodeToString(sum[:])
+}
+
+// imageReferences returns the normalized references of the job's container
+// images, without duplicates, pinned to digests if the config has a resolver.
+func (job *Job) imageReferences(cfg *ProvenanceConfig) ([]string, error) {
+	var refs []string
+	seen := make(map[string]bool)
+	for _, image := range job.ContainerImages() {
+		ref, err := image.Reference()
+		if err != nil {
+			return nil, err
+		}
+		if !ref.IsPinned() && cfg != nil && cfg.ResolveDigest != nil {
+			digest, err := cfg.ResolveDigest(ref)
+			if err != nil {
+				return nil, fmt.Errorf("unable to resolve the digest of %s: %w", ref, err)
+			}
+			ref.Digest = digest
+		}
+		s := ref.String()
+		if !seen[s] {
+			seen[s] = true
+			refs = append(refs, s)
+		}
+	}
+	return refs, nil
+}
+
+// ProvenanceMetadata returns AVUs describing how the job's outputs were
+// produced: the app name, submitter, submission date, container images,
+// parameters hash, batch ID and execution target. Fields that aren't set on the
+// job are left out. Commas and control characters in the values are replaced
+// by spaces, so the AVUs pass FileMetadata.Validate(). A nil config uses the
+// defaults.
+func (job *Job) ProvenanceMetadata(cfg *ProvenanceConfig) ([]FileMetadata, error) {
+	var md []FileMetadata
+	add := func(name, value, unit string) {
+		if value = provenanceValue(value); value != "" {
+			md = append(md, FileMetadata{Attribute: cfg.attribute(name), Value: value, Unit: unit})
+		}
+	}
+
+	add(ProvenanceAppName, job.AppName, "")
+	add(ProvenanceSubmitter, job.Submitter, "")
+	add(ProvenanceSubmissionDate, job.SubmissionDate, "")
+
+	refs, err := job.imageReferences(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, ref := range refs {
+		add(ProvenanceImage, ref, "")
+	}
+
+	add(ProvenanceParametersHash, job.ParametersHash(), "sha256")
+	add(ProvenanceBatchID, job.BatchID, "UUID")
+	add(ProvenanceExecutionTarget, job.ExecutionTarget, "")
+	return md, nil
+}
+
+// AddProvenanceMetadata adds the provenance AVUs to Job.FileMetadata, replacing
+// any provenance AVUs that are already there. Every existing provenance
+// attribute is removed first, so an AVU for a field that's no longer set, such
+// as the batch ID of a relaunched job, doesn't survive. Call it once the job is
+// final, e.g. after expanding a batch or overriding parameters, so that the
+// AVUs match what actually runs.
+func (job *Job) AddProvenanceMetadata(cfg *ProvenanceConfig) error {
+	md, err := job.ProvenanceMetadata(cfg)
+	if err != nil {
+		return err
+	}
+	provenance := make(map[string]bool)
+	for _, name := range provenanceNames {
+		provenance[cfg.attribute(name)] = true
+	}
+	var kept []FileMetadata
+	for _, m := range job.FileMetadata {
+		if !provenance[m.Attribute] {
+			kept = append(kept, m)
+		}
+	}
+	job.FileMetadata = kept
+	return job.MergeMetadata(md, MergeAppend)
+}
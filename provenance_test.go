@@ -0,0 +1,137 @@
+package model
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParametersHash(t *testing.T) {
+	s := _inittests(t, false)
+	h := s.ParametersHash()
+	if len(h) != 64 {
+		t.Errorf("ParametersHash() returned %q", h)
+	}
+	if s.Clone().ParametersHash() != h {
+		t.Error("ParametersHash() returned different hashes for the same parameters")
+	}
+
+	changed := s.Clone()
+	changed.Steps[0].Config.Params[0].Value = "something else"
+	if changed.ParametersHash() == h {
+		t.Error("ParametersHash() didn't change when a parameter value changed")
+	}
+}
+
+func TestProvenanceMetadata(t *testing.T) {
+	s := _inittests(t, false)
+	s.BatchID = "batch"
+	md, err := s.ProvenanceMetadata(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []FileMetadata{
+		{"ipc-app-name", "Word Count", ""},
+		{"ipc-submitter", s.Submitter, ""},
+		{"ipc-submission-date", s.SubmissionDate, ""},
+		{"ipc-container-image", "gims.iplantcollaborative.org:5000/backwards-compat:latest", ""},
+		{"ipc-parameters-hash", s.ParametersHash(), "sha256"},
+		{"ipc-batch-id", "batch", "UUID"},
+		{"ipc-execution-target", "condor", ""},
+	}
+	if !reflect.DeepEqual(md, expected) {
+		t.Errorf("ProvenanceMetadata() returned\n%#v\ninstead of\n%#v", md, expected)
+	}
+}
+
+func TestProvenanceMetadataConfig(t *testing.T) {
+	s := _inittests(t, false)
+	digest := "sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+	cfg := &ProvenanceConfig{
+		Prefix: "prov-",
+		ResolveDigest: func(ref ImageReference) (string, error) {
+			return digest, nil
+		},
+	}
+	md, err := s.ProvenanceMetadata(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, m := range md {
+		if m.Attribute == "ipc-app-name" {
+			t.Error("the prefix wasn't applied")
+		}
+		if m.Attribute == "prov-container-image" {
+			found = true
+			if m.Value != "gims.iplantcollaborative.org:5000/backwards-compat:latest@"+digest {
+				t.Errorf("the image reference was %s", m.Value)
+			}
+		}
+	}
+	if !found {
+		t.Error("the container image AVU is missing")
+	}
+
+	cfg.ResolveDigest = func(ref ImageReference) (string, error) {
+		return "", errors.New("registry unavailable")
+	}
+	if _, err := s.ProvenanceMetadata(cfg); err == nil {
+		t.Error("ProvenanceMetadata() didn't return the resolver's error")
+	}
+}
+
+func TestAddProvenanceMetadata(t *testing.T) {
+	s := _inittests(t, false)
+	if err := s.AddProvenanceMetadata(nil); err != nil {
+		t.Fatal(err)
+	}
+	count := len(s.FileMetadata)
+
+	s.ExecutionTarget = "interapps"
+	if err := s.AddProvenanceMetadata(nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(s.FileMetadata) != count {
+		t.Errorf("the metadata grew from %d to %d AVUs", count, len(s.FileMetadata))
+	}
+	var targets []string
+	for _, m := range s.FileMetadata {
+		if m.Attribute == "ipc-execution-target" {
+			targets = append(targets, m.Value)
+		}
+	}
+	if !reflect.DeepEqual(targets, []string{"interapps"}) {
+		t.Errorf("the execution target AVUs were %v", targets)
+	}
+	if err := s.ValidateMetadata(); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddProvenanceMetadataRemovesStale(t *testing.T) {
+	s := _inittests(t, false)
+	s.AppName = "Word Count, v2"
+	s.BatchID = "batch"
+	if err := s.AddProvenanceMetadata(nil); err != nil {
+		t.Fatal(err)
+	}
+
+	s.BatchID = ""
+	if err := s.AddProvenanceMetadata(nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range s.FileMetadata {
+		switch m.Attribute {
+		case "ipc-batch-id":
+			t.Errorf("the batch ID AVU survived: %#v", m)
+		case "ipc-app-name":
+			if m.Value != "Word Count v2" {
+				t.Errorf("the app name AVU was %q", m.Value)
+			}
+		}
+	}
+	if err := s.ValidateMetadata(); err != nil {
+		t.Error(err)
+	}
+}
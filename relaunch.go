@@ -0,0 +1,107 @@
+package model
+
+import (
+	"fmt"
+	"path"
+	"strings"
+	"time"
+)
+
+// RelaunchOptions contains the changes to make when relaunching a job.
+type RelaunchOptions struct {
+	// The name of the new job. Defaults to the original job's name.
+	Name string
+
+	// New values for StepParams, keyed by parameter ID. Every parameter with the
+	// ID is updated.
+	ParamOverrides map[string]string
+
+	// Keeps the original job's output directory and its ticket. By default the
+	// new job gets a directory of its own, next to the original one if the
+	// original job's OutputDir was used without a subdirectory.
+	ReuseOutputDir bool
+
+	// The clock used for NowDate and SubmissionDate. Defaults to time.Now.
+	Clock Clock
+
+	// Formats NowDate and SubmissionDate in UTC. See WithUTC().
+	UTC bool
+
+	// Generates the InvocationID. Defaults to NewUUID.
+	NewID IDGenerator
+}
+
+// resetRuntimeState clears the fields that are filled in while a job is
+// submitted and run, and the paths of the files generated for it.
+func (job *Job) resetRuntimeState() {
+	job.ID = ""
+	job.CondorID = ""
+	job.ExitCode = 0
+	job.FailureCount = 0
+	job.DateSubmitted = time.Time{}
+	job.DateStarted = time.Time{}
+	job.DateCompleted = time.Time{}
+	job.ConfigFile = ""
+	job.InputPathListFile = ""
+	job.InputTicketsFile = ""
+	job.OutputTicketFile = ""
+	job.BatchID = ""
+}
+
+// freshOutputDir makes the job's output directory depend on its name and
+// NowDate again, so that it doesn't write into the directory of the job it was
+// copied from. An OutputDir that was used without a subdirectory is replaced by
+// its parent.
+func (job *Job) freshOutputDir() {
+	job.OutputDirTicket = ""
+	if job.OutputDir == "" || job.CreateOutputSubdir {
+		return
+	}
+	job.OutputDir = path.Dir(strings.TrimSuffix(job.OutputDir, "/"))
+	job.CreateOutputSubdir = true
+}
+
+// Relaunch returns a new job based on a previously submitted one. The runtime
+// state and batch ID are reset, the job gets a new NowDate, SubmissionDate and
+// InvocationID, and the ipc-execution-id AVU is updated to match. Unless
+// opts.ReuseOutputDir is set, the job also gets a new output directory and the
+// output directory ticket is cleared. A name that already ends with a timestamp
+// is used as the directory name as it is, so that timestamp is replaced by the
+// new NowDate. The original job isn't modified.
+func (job *Job) Relaunch(opts RelaunchOptions) (*Job, error) {
+	relaunched := job.Clone()
+	relaunched.resetRuntimeState()
+	if !opts.ReuseOutputDir {
+		relaunched.freshOutputDir()
+	}
+
+	if opts.Name != "" {
+		relaunched.Name = sanitize(opts.Name)
+	}
+
+	for id, value := range opts.ParamOverrides {
+		found := false
+		for s := range relaunched.Steps {
+			params := relaunched.Steps[s].Config.Params
+			for i := range params {
+				if params[i].ID == id {
+					params[i].Value = value
+					found = true
+				}
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("the job has no parameter with ID %s", id)
+		}
+	}
+
+	n := timestamp(opts.Clock, opts.UTC)
+	relaunched.NowDate = n
+	relaunched.SubmissionDate = n
+	if !opts.ReuseOutputDir && validName.MatchString(relaunched.Name) {
+		relaunched.Name = validName.ReplaceAllString(relaunched.Name, "-"+n)
+	}
+	relaunched.InvocationID = newID(opts.NewID)
+	relaunched.setMetadata("ipc-execution-id", relaunched.InvocationID, "UUID")
+	return relaunched, nil
+}
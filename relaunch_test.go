@@ -0,0 +1,121 @@
+package model
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRelaunch(t *testing.T) {
+	s := _inittests(t, false)
+	s.ID = "job-id"
+	s.CondorID = "1234.0"
+	s.ExitCode = 1
+	s.FailureCount = 2
+	s.DateSubmitted = time.Now()
+	s.DateStarted = time.Now()
+	s.DateCompleted = time.Now()
+	s.ConfigFile = "/path/to/config"
+	s.NowDate = "2001-01-01-01-01-01.000"
+	s.SubmissionDate = s.NowDate
+
+	r, err := s.Relaunch(RelaunchOptions{
+		Name:           "relaunched analysis",
+		ParamOverrides: map[string]string{"45bb4960-cfb1-11eb-9e49-008cfa5ae621": "five"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if r.ID != "" || r.CondorID != "" || r.ExitCode != 0 || r.FailureCount != 0 || r.ConfigFile != "" {
+		t.Errorf("Relaunch() didn't reset the runtime state: %q %q %d %d %q", r.ID, r.CondorID, r.ExitCode, r.FailureCount, r.ConfigFile)
+	}
+	if !r.DateSubmitted.IsZero() || !r.DateStarted.IsZero() || !r.DateCompleted.IsZero() {
+		t.Error("Relaunch() didn't reset the dates")
+	}
+	if r.NowDate == s.NowDate || r.SubmissionDate != r.NowDate {
+		t.Errorf("Relaunch() set NowDate to '%s' and SubmissionDate to '%s'", r.NowDate, r.SubmissionDate)
+	}
+	if r.InvocationID == "" || r.InvocationID == s.InvocationID {
+		t.Errorf("Relaunch() set InvocationID to '%s'", r.InvocationID)
+	}
+	if r.Name != "relaunched_analysis" {
+		t.Errorf("Relaunch() set the name to '%s' instead of 'relaunched_analysis'", r.Name)
+	}
+	if r.Steps[0].Config.Params[9].Value != "five" {
+		t.Errorf("Relaunch() set param4 to '%s' instead of 'five'", r.Steps[0].Config.Params[9].Value)
+	}
+	for _, md := range r.FileMetadata {
+		if md.Attribute == "ipc-execution-id" && md.Value != r.InvocationID {
+			t.Errorf("ipc-execution-id was '%s' instead of '%s'", md.Value, r.InvocationID)
+		}
+	}
+
+	if s.CondorID != "1234.0" || s.Steps[0].Config.Params[9].Value != "four" {
+		t.Error("Relaunch() modified the original job")
+	}
+}
+
+func TestRelaunchOutputDir(t *testing.T) {
+	s := _inittests(t, false)
+	s.OutputDir = "/iplant/home/wregglej/analyses/original"
+	s.CreateOutputSubdir = false
+	s.OutputDirTicket = "ticket"
+	s.BatchID = "batch"
+
+	r, err := s.Relaunch(RelaunchOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.BatchID != "" || r.OutputDirTicket != "" {
+		t.Errorf("Relaunch() kept the batch ID '%s' and ticket '%s'", r.BatchID, r.OutputDirTicket)
+	}
+	expected := "/iplant/home/wregglej/analyses/" + r.DirectoryName()
+	if r.OutputDirectory() != expected {
+		t.Errorf("OutputDirectory() returned '%s' instead of '%s'", r.OutputDirectory(), expected)
+	}
+
+	r, err = s.Relaunch(RelaunchOptions{ReuseOutputDir: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.OutputDirectory() != s.OutputDirectory() || r.OutputDirTicket != "ticket" {
+		t.Errorf("Relaunch() changed the output directory to '%s' and ticket to '%s'", r.OutputDirectory(), r.OutputDirTicket)
+	}
+	if r.BatchID != "" {
+		t.Errorf("Relaunch() kept the batch ID '%s'", r.BatchID)
+	}
+}
+
+func TestRelaunchTimestampedName(t *testing.T) {
+	s := _inittests(t, false)
+	s.Name = "wc-2024-05-01-12-00-00.000"
+	s.OutputDir = ""
+	clock := func() time.Time { return time.Date(2024, 6, 1, 8, 30, 0, 0, time.UTC) }
+
+	r, err := s.Relaunch(RelaunchOptions{Clock: clock, UTC: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Name != "wc-2024-06-01-08-30-00.000" {
+		t.Errorf("the relaunched job's name was '%s'", r.Name)
+	}
+	if r.OutputDirectory() == s.OutputDirectory() {
+		t.Errorf("the relaunched job uses the original output directory '%s'", s.OutputDirectory())
+	}
+
+	r, err = s.Relaunch(RelaunchOptions{Clock: clock, UTC: true, ReuseOutputDir: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Name != s.Name || r.OutputDirectory() != s.OutputDirectory() {
+		t.Errorf("ReuseOutputDir changed the name to '%s' and the directory to '%s'", r.Name, r.OutputDirectory())
+	}
+}
+
+func TestRelaunchUnknownParam(t *testing.T) {
+	s := _inittests(t, false)
+	_, err := s.Relaunch(RelaunchOptions{ParamOverrides: map[string]string{"not-a-param": "value"}})
+	if err == nil {
+		t.Error("Relaunch() did not return an error for an unknown parameter")
+	}
+}
@@ -0,0 +1,185 @@
+package model
+
+// ROCrateMetadataFile is the name of the file that contains an RO-Crate's
+// metadata.
+const ROCrateMetadataFile = "ro-crate-metadata.json"
+
+// The contexts and profiles used by the RO-Crates describing jobs. The
+// workflow run context defines the ContainerImage type.
+const (
+	ROCrateContext            = "https://w3id.org/ro/crate/1.1/context"
+	ROCrateSpecification      = "https://w3id.org/ro/crate/1.1"
+	ROCrateWorkflowRunContext = "https://w3id.org/ro/terms/workflow-run/context"
+	ROCrateProcessRunProfile  = "https://w3id.org/ro/wfrun/process/0.5"
+)
+
+// ROCrateEntity is an entity in an RO-Crate's JSON-LD graph. Entities have
+// different properties depending on their types, so they're kept as maps.
+type ROCrateEntity map[string]any
+
+// ROCrate is the contents of an ro-crate-metadata.json file.
+type ROCrate struct {
+	Context []string        `json:"@context"`
+	Graph   []ROCrateEntity `json:"@graph"`
+}
+
+// ref returns a JSON-LD reference to the entity with the ID.
+func ref(id string) map[string]string {
+	return map[string]string{"@id": id}
+}
+
+// refs returns JSON-LD references to the entities with the IDs.
+func refs(ids []string) []map[string]string {
+	r := make([]map[string]string, 0, len(ids))
+	for _, id := range ids {
+		r = append(r, ref(id))
+	}
+	return r
+}
+
+// setIfNotEmpty adds the property to the entity if the value isn't empty.
+func (e ROCrateEntity) setIfNotEmpty(property, value string) {
+	if value != "" {
+		e[property] = value
+	}
+}
+
+// entity returns the RO-Crate entity describing an input or output.
+func (f exportFile) entity() ROCrateEntity {
+	t := "File"
+	if f.collection {
+		t = "Dataset"
+	}
+	return ROCrateEntity{"@id": f.uri, "@type": t, "name": f.name}
+}
+
+// actionStatus returns the schema.org ActionStatusType for a job state.
+func actionStatus(state string) string {
+	switch state {
+	case StatusCompleted:
+		return "http://schema.org/CompletedActionStatus"
+	case StatusFailed, StatusCanceled:
+		return "http://schema.org/FailedActionStatus"
+	case "":
+		return ""
+	}
+	return "http://schema.org/ActiveActionStatus"
+}
+
+// ROCrate describes the job as a Process Run Crate: a CreateAction for the
+// execution, with the app as the instrument, the submitter as the agent, the
+// inputs and parameters as objects, and the outputs as results. The inputs and
+// outputs are identified by irods:// URIs. The timeline is optional; it fills
+// in the times that the job's Date* fields don't have. Schema.org has no
+// property for the submission time, so it's only included by PROV().
+func (job *Job) ROCrate(timeline []StatusUpdate, cfg *ExportConfig) (*ROCrate, error) {
+	r, err := job.exportRecord(timeline, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	executionID := "#execution-" + job.InvocationID
+	appID := "#app-" + job.AppID
+	agentID := "#user-" + job.Submitter
+
+	var graph []ROCrateEntity
+	var inputIDs, outputIDs, paramIDs, toolIDs []string
+	for _, f := range r.inputs {
+		inputIDs = append(inputIDs, f.uri)
+		graph = append(graph, f.entity())
+	}
+	for _, f := range r.outputs {
+		outputIDs = append(outputIDs, f.uri)
+		graph = append(graph, f.entity())
+	}
+	for _, p := range r.params {
+		id := "#" + p.id
+		paramIDs = append(paramIDs, id)
+		name := p.param.Name
+		if name == "" {
+			name = p.param.ID
+		}
+		graph = append(graph, ROCrateEntity{
+			"@id":        id,
+			"@type":      "PropertyValue",
+			"propertyID": p.param.ID,
+			"name":       name,
+			"value":      p.param.Value,
+		})
+	}
+	for _, image := range r.images {
+		graph = append(graph, ROCrateEntity{
+			"@id":   "docker://" + image,
+			"@type": "ContainerImage",
+			"name":  image,
+		})
+	}
+	for _, t := range r.tools {
+		id := "#" + t.id
+		toolIDs = append(toolIDs, id)
+		tool := ROCrateEntity{
+			"@id":                  id,
+			"@type":                "SoftwareApplication",
+			"name":                 t.component.Name,
+			"softwareRequirements": ref("docker://" + t.image),
+		}
+		tool.setIfNotEmpty("description", t.component.Description)
+		graph = append(graph, tool)
+	}
+
+	app := ROCrateEntity{
+		"@id":     appID,
+		"@type":   "SoftwareApplication",
+		"name":    job.AppName,
+		"hasPart": refs(toolIDs),
+	}
+	app.setIfNotEmpty("description", job.AppDescription)
+	agent := ROCrateEntity{
+		"@id":   agentID,
+		"@type": "Person",
+		"name":  job.Submitter,
+	}
+
+	action := ROCrateEntity{
+		"@id":        executionID,
+		"@type":      "CreateAction",
+		"name":       job.Name,
+		"instrument": ref(appID),
+		"agent":      ref(agentID),
+		"object":     refs(append(inputIDs, paramIDs...)),
+		"result":     refs(outputIDs),
+	}
+	action.setIfNotEmpty("description", job.Description)
+	action.setIfNotEmpty("startTime", exportTime(r.started))
+	action.setIfNotEmpty("endTime", exportTime(r.completed))
+	action.setIfNotEmpty("actionStatus", actionStatus(r.state))
+
+	root := ROCrateEntity{
+		"@id":        "./",
+		"@type":      "Dataset",
+		"name":       job.Name,
+		"conformsTo": ref(ROCrateProcessRunProfile),
+		"hasPart":    refs(outputIDs),
+		"mentions":   ref(executionID),
+	}
+	root.setIfNotEmpty("description", job.Description)
+	root.setIfNotEmpty("datePublished", exportTime(r.completed))
+
+	metadata := ROCrateEntity{
+		"@id":        ROCrateMetadataFile,
+		"@type":      "CreativeWork",
+		"conformsTo": ref(ROCrateSpecification),
+		"about":      ref("./"),
+	}
+	profile := ROCrateEntity{
+		"@id":     ROCrateProcessRunProfile,
+		"@type":   "CreativeWork",
+		"name":    "Process Run Crate",
+		"version": "0.5",
+	}
+
+	return &ROCrate{
+		Context: []string{ROCrateContext, ROCrateWorkflowRunContext},
+		Graph:   append([]ROCrateEntity{metadata, root, profile, action, app, agent}, graph...),
+	}, nil
+}
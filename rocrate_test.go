@@ -0,0 +1,104 @@
+package model
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// findEntity returns the entity in the crate with the ID, or nil.
+func findEntity(crate *ROCrate, id string) ROCrateEntity {
+	for _, e := range crate.Graph {
+		if e["@id"] == id {
+			return e
+		}
+	}
+	return nil
+}
+
+func TestROCrate(t *testing.T) {
+	s := _inittests(t, false)
+	s.DateStarted = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	s.DateCompleted = time.Date(2024, 5, 1, 13, 0, 0, 0, time.UTC)
+	timeline := []StatusUpdate{{State: StatusCompleted, SentOn: s.DateCompleted}}
+
+	crate, err := s.ROCrate(timeline, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	metadata := findEntity(crate, ROCrateMetadataFile)
+	if metadata == nil || metadata["@type"] != "CreativeWork" {
+		t.Errorf("the metadata descriptor was %#v", metadata)
+	}
+	root := findEntity(crate, "./")
+	if root == nil || root["datePublished"] != "2024-05-01T13:00:00Z" {
+		t.Errorf("the root data entity was %#v", root)
+	}
+
+	action := findEntity(crate, "#execution-"+s.InvocationID)
+	if action == nil {
+		t.Fatal("the crate has no CreateAction")
+	}
+	if action["startTime"] != "2024-05-01T12:00:00Z" || action["endTime"] != "2024-05-01T13:00:00Z" {
+		t.Errorf("the action's times were %v and %v", action["startTime"], action["endTime"])
+	}
+	if action["actionStatus"] != "http://schema.org/CompletedActionStatus" {
+		t.Errorf("the action's status was %v", action["actionStatus"])
+	}
+	if agent := findEntity(crate, "#user-"+s.Submitter); agent == nil || agent["@type"] != "Person" {
+		t.Errorf("the agent was %#v", agent)
+	}
+	if app := findEntity(crate, "#app-"+s.AppID); app == nil || app["name"] != "Word Count" {
+		t.Errorf("the app was %#v", app)
+	}
+	image := findEntity(crate, "docker://gims.iplantcollaborative.org:5000/backwards-compat:latest")
+	if image == nil || image["@type"] != "ContainerImage" {
+		t.Errorf("the container image was %#v", image)
+	}
+	input := findEntity(crate, "irods:///iplant/home/wregglej/Acer-tree.txt")
+	if input == nil || input["@type"] != "File" {
+		t.Errorf("the input was %#v", input)
+	}
+	output := findEntity(crate, "irods://"+s.OutputDirectory()+"/logs/")
+	if output == nil || output["@type"] != "Dataset" {
+		t.Errorf("the logs output was %#v", output)
+	}
+
+	// Every reference must point to an entity in the crate.
+	for _, e := range crate.Graph {
+		for property, value := range e {
+			var ids []map[string]string
+			switch v := value.(type) {
+			case map[string]string:
+				ids = append(ids, v)
+			case []map[string]string:
+				ids = v
+			}
+			for _, r := range ids {
+				if findEntity(crate, r["@id"]) == nil && r["@id"] != ROCrateSpecification {
+					t.Errorf("%s of %s refers to the missing entity %s", property, e["@id"], r["@id"])
+				}
+			}
+		}
+	}
+
+	if _, err := json.Marshal(crate); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestActionStatus(t *testing.T) {
+	cases := map[string]string{
+		"":              "",
+		StatusRunning:   "http://schema.org/ActiveActionStatus",
+		StatusCompleted: "http://schema.org/CompletedActionStatus",
+		StatusFailed:    "http://schema.org/FailedActionStatus",
+		StatusCanceled:  "http://schema.org/FailedActionStatus",
+	}
+	for state, expected := range cases {
+		if actual := actionStatus(state); actual != expected {
+			t.Errorf("actionStatus(%q) returned %q instead of %q", state, actual, expected)
+		}
+	}
+}
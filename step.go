@@ -1,7 +1,6 @@
 package model
 
 import (
-	"bytes"
 	"fmt"
 	"path"
 	"sort"
@@ -36,13 +35,17 @@ type Step struct {
 	Environment StepEnvironment `json:"environment"`
 	Input       []StepInput     `json:"input"`
 	Output      []StepOutput    `json:"output"`
+
+	backwardsCompat *BackwardsCompatClassifier // set by Job.SetBackwardsCompat().
+	layout          *Layout                    // set by Job.SetLayout().
 }
 
 // EnvOptions returns a string containing the docker command-line options
-// that set the environment variables listed in the Environment field.
+// that set the environment variables listed in the Environment field and the
+// environment variable parameters.
 func (s *Step) EnvOptions() []string {
 	retval := []string{}
-	for k, v := range s.Environment {
+	for k, v := range s.EnvironmentWithParams() {
 		retval = append(retval, fmt.Sprintf("--env=\"%s=%s\"", k, v))
 	}
 	return retval
@@ -50,13 +53,22 @@ func (s *Step) EnvOptions() []string {
 
 // IsBackwardsCompatible returns true if the job submission uses the container
 // image(s) put together to maintain compatibility with non-dockerized versions
-// of the DE.
+// of the DE. The images are matched against the step's classifier, which
+// defaults to DefaultBackwardsCompat().
 func (s *Step) IsBackwardsCompatible() bool {
-	img := s.Component.Container.Image.Name
-	return strings.HasPrefix(img, "discoenv/backwards-compat") ||
-		strings.HasPrefix(img, "gims.iplantcollaborative.org:5000/backwards-compat") ||
-		strings.HasPrefix(img, "docker.cyverse.org/backwards-compat") ||
-		strings.HasPrefix(img, "harbor.cyverse.org/legacy/backwards-compat")
+	_, matched := s.BackwardsCompatMatch()
+	return matched
+}
+
+// BackwardsCompatMatch returns the backwards compatible image pattern that
+// matched the step's image and true, or an empty string and false if the image
+// isn't backwards compatible.
+func (s *Step) BackwardsCompatMatch() (string, bool) {
+	c := s.backwardsCompat
+	if c == nil {
+		c = defaultBackwardsCompat
+	}
+	return c.Classify(s.Component.Container.Image.Name)
 }
 
 // UsesVolumes returns a boolean value which indicates if a step uses host-mounted volumes
@@ -74,17 +86,17 @@ func (s *Step) Executable() string {
 }
 
 // Arguments returns a []string containing all of the options passed to the
-// docker run command for this step in the submission.
+// docker run command for this step in the submission. The parameters are
+// rendered with DefaultParamRenderer.
 func (s *Step) Arguments() []string {
+	return s.ArgumentsWith(&DefaultParamRenderer)
+}
+
+// ArgumentsWith returns the same options as Arguments(), rendering the
+// parameters with the given ParamRenderer.
+func (s *Step) ArgumentsWith(r *ParamRenderer) []string {
 	allLines := []string{strings.TrimSpace(s.Executable())}
-	for _, p := range s.Config.Parameters() {
-		if p.Name != "" {
-			allLines = append(allLines, strings.TrimSpace(p.Name))
-		}
-		if p.Value != "" {
-			allLines = append(allLines, strings.TrimSpace(p.Value))
-		}
-	}
+	allLines = append(allLines, r.Render(s.Config.Parameters())...)
 	var cmdLine []string
 	for _, l := range allLines {
 		if l != "" {
@@ -110,7 +122,7 @@ func (s *Step) Stdout(suffix string) string {
 	if s.StdoutPath != "" {
 		return s.StdoutPath
 	}
-	return path.Join("logs", fmt.Sprintf("%s%s", "condor-stdout-", suffix))
+	return s.layout.LogFile(fmt.Sprintf("%s%s", "condor-stdout-", suffix))
 }
 
 // Stderr returns the quoted version of s.StderrPath or a default value located in
@@ -120,7 +132,7 @@ func (s *Step) Stderr(suffix string) string {
 	if s.StderrPath != "" {
 		return s.StderrPath
 	}
-	return path.Join("logs", fmt.Sprintf("%s%s", "condor-stderr-", suffix))
+	return s.layout.LogFile(fmt.Sprintf("%s%s", "condor-stderr-", suffix))
 }
 
 // LogPath uses the value of step.LogFile and params to generate a path to a
@@ -131,7 +143,7 @@ func (s *Step) LogPath(parent, suffix string) string {
 	if s.LogFile != "" {
 		return path.Join(parent, s.LogFile)
 	}
-	return path.Join(parent, "logs", fmt.Sprintf("condor-log-%s", suffix))
+	return path.Join(parent, s.layout.LogFile(fmt.Sprintf("condor-log-%s", suffix)))
 }
 
 // StepConfig is where configuration settings for a job step are located.
@@ -181,11 +193,9 @@ func (o ByOrder) Less(i, j int) bool {
 // that previews the command-line for a submission.
 type PreviewableStepParam []StepParam
 
+// String renders the parameters the same way as Step.Arguments() and quotes
+// them for a POSIX shell.
 func (p PreviewableStepParam) String() string {
-	var buffer bytes.Buffer
-	sort.Sort(ByOrder(p))
-	for _, param := range p {
-		buffer.WriteString(fmt.Sprintf("%s %s ", param.Name, param.Value))
-	}
-	return strings.TrimSpace(buffer.String())
+	sort.Stable(ByOrder(p))
+	return shellJoin(DefaultParamRenderer.Render(p))
 }
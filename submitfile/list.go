@@ -32,6 +32,12 @@ func escapeChar(orig string) string {
 	}
 }
 
+// FormatString converts a string to a quoted string literal that can be placed in
+// an HTCondor submit file.
+func FormatString(s string) string {
+	return `"` + escapeCharsRegexp.ReplaceAllStringFunc(s, escapeChar) + `"`
+}
+
 // FormatList converts a slice of strings to a formatted list that can be placed in
 // an HTCondor submit file.
 func FormatList(l []string) string {
@@ -42,9 +48,7 @@ func FormatList(l []string) string {
 		if index > 0 {
 			result.WriteRune(',')
 		}
-		result.WriteRune('"')
-		result.WriteString(escapeCharsRegexp.ReplaceAllStringFunc(group, escapeChar))
-		result.WriteRune('"')
+		result.WriteString(FormatString(group))
 	}
 	result.WriteRune('}')
 
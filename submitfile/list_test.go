@@ -31,3 +31,17 @@ func TestFormatList(t *testing.T) {
 	checkList(t, []string{"foo", "bar\\"}, `{"foo","bar\\"}`)
 	checkList(t, []string{"groups:foo", "groups:bar"}, `{"groups:foo","groups:bar"}`)
 }
+
+func TestFormatString(t *testing.T) {
+	strs := map[string]string{
+		"":                     `""`,
+		"/path/to/image":       `"/path/to/image"`,
+		"a \"quoted\" 'value'": `"a \"quoted\" \'value\'"`,
+		"back\\slash\n":        `"back\\slash\n"`,
+	}
+	for s, expected := range strs {
+		if actual := FormatString(s); actual != expected {
+			t.Errorf("Unexpected string format: actual `%s`; expected `%s`", actual, expected)
+		}
+	}
+}
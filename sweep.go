@@ -0,0 +1,250 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// SweepMode determines how the dimensions of a parameter sweep are combined.
+type SweepMode string
+
+const (
+	// SweepProduct runs every combination of the dimensions' values. The first
+	// dimension changes the slowest.
+	SweepProduct SweepMode = "product"
+
+	// SweepZip pairs up the values of the dimensions by position. Every
+	// dimension must have the same number of values.
+	SweepZip SweepMode = "zip"
+
+	// SweepList runs an explicit list of points.
+	SweepList SweepMode = "list"
+)
+
+// SweepMetadataPrefix is prepended to the parameter's label and ID to form the
+// attribute of the AVU that records a sweep coordinate.
+const SweepMetadataPrefix = "ipc-sweep-"
+
+// SweepDimension is a parameter and the values it takes in a sweep.
+type SweepDimension struct {
+	ParamID string   `json:"param_id"`
+	Values  []string `json:"values"`
+}
+
+// SweepCoordinate is the value of one parameter at a point in a sweep.
+type SweepCoordinate struct {
+	ParamID string `json:"param_id"`
+	Value   string `json:"value"`
+}
+
+// SweepPoint is a single run in a sweep.
+type SweepPoint []SweepCoordinate
+
+// SweepSpec describes a parameter sweep.
+type SweepSpec struct {
+	Mode SweepMode `json:"mode"`
+
+	// Used by SweepProduct and SweepZip.
+	Dimensions []SweepDimension `json:"dimensions,omitempty"`
+
+	// Used by SweepList.
+	Points []SweepPoint `json:"points,omitempty"`
+
+	// The ID that all of the child jobs share. Defaults to the template's
+	// InvocationID.
+	BatchID string `json:"batch_id,omitempty"`
+
+	// The maximum number of child jobs. Defaults to DefaultMaxBatchSize.
+	MaxJobs int `json:"max_jobs,omitempty"`
+
+	// Generates the children's InvocationIDs, and the batch ID if there isn't
+	// one. Defaults to NewUUID.
+	NewID IDGenerator `json:"-"`
+}
+
+// IntRange returns the integers from start to end, inclusive, counting by step,
+// formatted as parameter values. IntRange(21, 61, 10) returns 21, 31, 41, 51
+// and 61.
+func IntRange(start, end, step int) ([]string, error) {
+	if step <= 0 {
+		return nil, fmt.Errorf("the step must be positive, not %d", step)
+	}
+	if end < start {
+		return nil, fmt.Errorf("the end of the range (%d) is before the start (%d)", end, start)
+	}
+	var values []string
+	for i := start; i <= end; i += step {
+		values = append(values, strconv.Itoa(i))
+	}
+	return values, nil
+}
+
+// Count returns the number of points in the sweep without expanding it, so
+// that callers can check the size of a sweep before creating any jobs.
+func (s *SweepSpec) Count() (int, error) {
+	switch s.Mode {
+	case SweepProduct, SweepZip:
+		if len(s.Dimensions) == 0 {
+			return 0, errors.New("the sweep has no dimensions")
+		}
+		count := 0
+		for i, d := range s.Dimensions {
+			if d.ParamID == "" {
+				return 0, fmt.Errorf("dimension %d has no parameter ID", i)
+			}
+			if len(d.Values) == 0 {
+				return 0, fmt.Errorf("dimension %d (%s) has no values", i, d.ParamID)
+			}
+			switch {
+			case i == 0:
+				count = len(d.Values)
+			case s.Mode == SweepProduct:
+				if count > math.MaxInt/len(d.Values) {
+					return 0, errors.New("the sweep is too large")
+				}
+				count *= len(d.Values)
+			case len(d.Values) != count:
+				return 0, fmt.Errorf("dimension %d (%s) has %d values instead of %d", i, d.ParamID, len(d.Values), count)
+			}
+		}
+		return count, nil
+	case SweepList:
+		if len(s.Points) == 0 {
+			return 0, errors.New("the sweep has no points")
+		}
+		return len(s.Points), nil
+	}
+	return 0, fmt.Errorf("unsupported sweep mode %q", s.Mode)
+}
+
+// Expand returns the points in the sweep, checking its size against the limit
+// first.
+func (s *SweepSpec) Expand() ([]SweepPoint, error) {
+	count, err := s.Count()
+	if err != nil {
+		return nil, err
+	}
+	maxJobs := s.MaxJobs
+	if maxJobs <= 0 {
+		maxJobs = DefaultMaxBatchSize
+	}
+	if count > maxJobs {
+		return nil, fmt.Errorf("the sweep would create %d jobs, which is more than the limit of %d", count, maxJobs)
+	}
+
+	if s.Mode == SweepList {
+		return s.Points, nil
+	}
+
+	points := make([]SweepPoint, count)
+	for i := range points {
+		point := make(SweepPoint, len(s.Dimensions))
+		rest := i
+		for d := len(s.Dimensions) - 1; d >= 0; d-- {
+			dim := s.Dimensions[d]
+			j := i
+			if s.Mode == SweepProduct {
+				j = rest % len(dim.Values)
+				rest /= len(dim.Values)
+			}
+			point[d] = SweepCoordinate{ParamID: dim.ParamID, Value: dim.Values[j]}
+		}
+		points[i] = point
+	}
+	return points, nil
+}
+
+// unsafeLabel matches the characters that get replaced in sweep labels.
+var unsafeLabel = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// sweepLabel makes a string safe to use in a directory name or AVU attribute.
+func sweepLabel(s string) string {
+	return strings.Trim(unsafeLabel.ReplaceAllString(s, "_"), "_")
+}
+
+// paramLabel returns a label for the parameter with the ID, based on its option
+// name with any leading dashes or trailing = removed. The ID is used if the
+// parameter doesn't have a name. Labels aren't unique, since parameters in
+// different steps may have the same name; see sweepAttribute().
+func (job *Job) paramLabel(id string) (string, bool) {
+	for _, step := range job.Steps {
+		for _, p := range step.Config.Params {
+			if p.ID != id {
+				continue
+			}
+			name := strings.TrimRight(strings.TrimLeft(strings.TrimSpace(p.Name), "-"), "=")
+			if label := sweepLabel(name); label != "" {
+				return label, true
+			}
+			return sweepLabel(id), true
+		}
+	}
+	return "", false
+}
+
+// sweepAttribute returns the attribute of the AVU that records the value of the
+// parameter with the label and ID. The ID keeps parameters with the same label
+// from overwriting each other's AVUs.
+func sweepAttribute(label, id string) string {
+	if idLabel := sweepLabel(id); idLabel != label {
+		label = fmt.Sprintf("%s.%s", label, idLabel)
+	}
+	return SweepMetadataPrefix + label
+}
+
+// ExpandSweep creates a child job from the template for each point in the
+// sweep, setting the value of every StepParam whose ID matches one of the
+// point's coordinates. Each child gets a new InvocationID, the batch ID, an
+// AVU for each coordinate, and an output directory inside the template's output
+// directory whose name includes the coordinates. The template isn't modified.
+func ExpandSweep(template *Job, spec SweepSpec) ([]*Job, error) {
+	points, err := spec.Expand()
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make(map[string]string)
+	for _, point := range points {
+		for _, c := range point {
+			if _, ok := labels[c.ParamID]; ok {
+				continue
+			}
+			label, ok := template.paramLabel(c.ParamID)
+			if !ok {
+				return nil, fmt.Errorf("the template has no parameter with ID %s", c.ParamID)
+			}
+			labels[c.ParamID] = label
+		}
+	}
+
+	batchID := template.batchID(spec.BatchID, spec.NewID)
+	parentDir := template.OutputDirectory()
+	children := make([]*Job, len(points))
+	for i, point := range points {
+		child := template.newChild(batchID, i, len(points), spec.NewID)
+
+		var dirParts []string
+		for _, c := range point {
+			for s := range child.Steps {
+				params := child.Steps[s].Config.Params
+				for j := range params {
+					if params[j].ID == c.ParamID {
+						params[j].Value = c.Value
+					}
+				}
+			}
+			label := labels[c.ParamID]
+			child.setMetadata(sweepAttribute(label, c.ParamID), c.Value, "")
+			dirParts = append(dirParts, fmt.Sprintf("%s-%s", label, sweepLabel(c.Value)))
+		}
+
+		child.OutputDir = path.Join(parentDir, fmt.Sprintf("%s_%s", child.Name, strings.Join(dirParts, "_")))
+		children[i] = child
+	}
+	return children, nil
+}
@@ -0,0 +1,184 @@
+package model
+
+import (
+	"path"
+	"reflect"
+	"testing"
+)
+
+const (
+	sweepParam3 = "45b9e6ba-cfb1-11eb-9e49-008cfa5ae621"
+	sweepParam4 = "45bb4960-cfb1-11eb-9e49-008cfa5ae621"
+)
+
+func TestIntRange(t *testing.T) {
+	actual, err := IntRange(21, 61, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"21", "31", "41", "51", "61"}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("IntRange() returned %#v instead of %#v", actual, expected)
+	}
+	if _, err := IntRange(1, 10, 0); err == nil {
+		t.Error("IntRange() did not return an error for a step of 0")
+	}
+	if _, err := IntRange(10, 1, 1); err == nil {
+		t.Error("IntRange() did not return an error for a backwards range")
+	}
+}
+
+func TestSweepCount(t *testing.T) {
+	ks, _ := IntRange(21, 61, 10)
+	cases := []struct {
+		spec     SweepSpec
+		expected int
+		err      bool
+	}{
+		{SweepSpec{Mode: SweepProduct, Dimensions: []SweepDimension{{sweepParam3, ks}, {sweepParam4, []string{"a", "b"}}}}, 10, false},
+		{SweepSpec{Mode: SweepZip, Dimensions: []SweepDimension{{sweepParam3, []string{"1", "2"}}, {sweepParam4, []string{"a", "b"}}}}, 2, false},
+		{SweepSpec{Mode: SweepZip, Dimensions: []SweepDimension{{sweepParam3, ks}, {sweepParam4, []string{"a", "b"}}}}, 0, true},
+		{SweepSpec{Mode: SweepList, Points: []SweepPoint{{{sweepParam3, "1"}}}}, 1, false},
+		{SweepSpec{Mode: SweepList}, 0, true},
+		{SweepSpec{Mode: SweepProduct}, 0, true},
+		{SweepSpec{Mode: SweepProduct, Dimensions: []SweepDimension{{sweepParam3, nil}}}, 0, true},
+		{SweepSpec{Mode: SweepProduct, Dimensions: []SweepDimension{{"", ks}}}, 0, true},
+		{SweepSpec{Mode: "bogus"}, 0, true},
+	}
+	for i, c := range cases {
+		actual, err := c.spec.Count()
+		if (err != nil) != c.err {
+			t.Errorf("case %d: Count() returned error %v", i, err)
+		}
+		if actual != c.expected {
+			t.Errorf("case %d: Count() returned %d instead of %d", i, actual, c.expected)
+		}
+	}
+}
+
+func TestSweepExpandProduct(t *testing.T) {
+	spec := SweepSpec{
+		Mode: SweepProduct,
+		Dimensions: []SweepDimension{
+			{sweepParam3, []string{"21", "31"}},
+			{sweepParam4, []string{"hg19", "hg38"}},
+		},
+	}
+	actual, err := spec.Expand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []SweepPoint{
+		{{sweepParam3, "21"}, {sweepParam4, "hg19"}},
+		{{sweepParam3, "21"}, {sweepParam4, "hg38"}},
+		{{sweepParam3, "31"}, {sweepParam4, "hg19"}},
+		{{sweepParam3, "31"}, {sweepParam4, "hg38"}},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expand() returned %#v instead of %#v", actual, expected)
+	}
+
+	spec.MaxJobs = 3
+	if _, err := spec.Expand(); err == nil {
+		t.Error("Expand() did not return an error for a sweep over the limit")
+	}
+}
+
+func TestSweepExpandZip(t *testing.T) {
+	spec := SweepSpec{
+		Mode: SweepZip,
+		Dimensions: []SweepDimension{
+			{sweepParam3, []string{"21", "31"}},
+			{sweepParam4, []string{"hg19", "hg38"}},
+		},
+	}
+	actual, err := spec.Expand()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []SweepPoint{
+		{{sweepParam3, "21"}, {sweepParam4, "hg19"}},
+		{{sweepParam3, "31"}, {sweepParam4, "hg38"}},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Expand() returned %#v instead of %#v", actual, expected)
+	}
+}
+
+func TestExpandSweep(t *testing.T) {
+	s := _inittests(t, false)
+	spec := SweepSpec{
+		Mode: SweepProduct,
+		Dimensions: []SweepDimension{
+			{sweepParam3, []string{"21", "31"}},
+			{sweepParam4, []string{"/ref/hg19.fa", "/ref/hg38.fa"}},
+		},
+	}
+	children, err := ExpandSweep(s, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(children) != 4 {
+		t.Fatalf("ExpandSweep() returned %d jobs instead of 4", len(children))
+	}
+
+	child := children[1]
+	params := child.Steps[0].Config.Params
+	if params[8].Value != "21" || params[9].Value != "/ref/hg38.fa" {
+		t.Errorf("the child's params were set to '%s' and '%s'", params[8].Value, params[9].Value)
+	}
+	expectedDir := path.Join(s.OutputDirectory(), child.Name+"_param3-21_param4-ref_hg38.fa")
+	if child.OutputDirectory() != expectedDir {
+		t.Errorf("the child's output directory was '%s' instead of '%s'", child.OutputDirectory(), expectedDir)
+	}
+	if child.BatchID != s.InvocationID {
+		t.Errorf("the child's BatchID was '%s' instead of '%s'", child.BatchID, s.InvocationID)
+	}
+
+	avus := make(map[string]string)
+	for _, md := range child.FileMetadata {
+		avus[md.Attribute] = md.Value
+	}
+	if avus["ipc-sweep-param3."+sweepParam3] != "21" || avus["ipc-sweep-param4."+sweepParam4] != "/ref/hg38.fa" {
+		t.Errorf("the child's sweep AVUs were %#v", avus)
+	}
+	if avus["ipc-execution-id"] != child.InvocationID {
+		t.Errorf("the child's ipc-execution-id was '%s' instead of '%s'", avus["ipc-execution-id"], child.InvocationID)
+	}
+
+	if s.Steps[0].Config.Params[8].Value != "true" {
+		t.Error("ExpandSweep() modified the template")
+	}
+}
+
+func TestExpandSweepSameName(t *testing.T) {
+	s := _inittests(t, false)
+	s.Steps[0].Config.Params[9].Name = "param3"
+	spec := SweepSpec{
+		Mode:   SweepList,
+		Points: []SweepPoint{{{sweepParam3, "21"}, {sweepParam4, "31"}}},
+	}
+	children, err := ExpandSweep(s, spec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	avus := make(map[string]string)
+	for _, md := range children[0].FileMetadata {
+		avus[md.Attribute] = md.Value
+	}
+	if avus["ipc-sweep-param3."+sweepParam3] != "21" || avus["ipc-sweep-param3."+sweepParam4] != "31" {
+		t.Errorf("the child's sweep AVUs were %#v", avus)
+	}
+	_inittests(t, false)
+}
+
+func TestExpandSweepUnknownParam(t *testing.T) {
+	s := _inittests(t, false)
+	spec := SweepSpec{
+		Mode:   SweepList,
+		Points: []SweepPoint{{{"not-a-param", "1"}}},
+	}
+	if _, err := ExpandSweep(s, spec); err == nil {
+		t.Error("ExpandSweep() did not return an error for an unknown parameter")
+	}
+}
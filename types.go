@@ -0,0 +1,321 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// unmarshalEnum decodes a JSON string and passes it through the normalization
+// function for the enum type. JSON nulls leave the value empty.
+func unmarshalEnum(data []byte, normalize func(string) string) (string, error) {
+	var s *string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return "", err
+	}
+	if s == nil {
+		return "", nil
+	}
+	return normalize(*s), nil
+}
+
+// Multiplicity describes how many files an input or output refers to.
+type Multiplicity string
+
+// The known multiplicities.
+const (
+	MultiplicitySingle     Multiplicity = "single"
+	MultiplicityMany       Multiplicity = "many"
+	MultiplicityCollection Multiplicity = "collection"
+	MultiplicityFolder     Multiplicity = "folder"
+)
+
+// Multiplicities contains every known Multiplicity.
+var Multiplicities = []Multiplicity{
+	MultiplicitySingle,
+	MultiplicityMany,
+	MultiplicityCollection,
+	MultiplicityFolder,
+}
+
+// legacyMultiplicities maps older spellings, in lower case, to the current
+// values.
+var legacyMultiplicities = map[string]Multiplicity{
+	"one":      MultiplicitySingle,
+	"file":     MultiplicitySingle,
+	"multiple": MultiplicityMany,
+	"files":    MultiplicityMany,
+}
+
+// ParseMultiplicity converts a multiplicity from a job submission into its
+// canonical spelling. Values are matched without regard to case, and legacy
+// spellings such as "One" are accepted. Unknown values are returned unchanged.
+func ParseMultiplicity(s string) Multiplicity {
+	lower := strings.ToLower(strings.TrimSpace(s))
+	for _, m := range Multiplicities {
+		if string(m) == lower {
+			return m
+		}
+	}
+	if m, ok := legacyMultiplicities[lower]; ok {
+		return m
+	}
+	return Multiplicity(s)
+}
+
+// UnmarshalJSON accepts the legacy spellings handled by ParseMultiplicity.
+func (m *Multiplicity) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalEnum(data, func(s string) string { return string(ParseMultiplicity(s)) })
+	*m = Multiplicity(s)
+	return err
+}
+
+// Known returns true if the multiplicity is one of the known values.
+func (m Multiplicity) Known() bool {
+	for _, k := range Multiplicities {
+		if m == k {
+			return true
+		}
+	}
+	return false
+}
+
+// IsCollection returns true if the multiplicity refers to a directory.
+func (m Multiplicity) IsCollection() bool {
+	return m == MultiplicityCollection || m == MultiplicityFolder
+}
+
+// ParamType is the type of a step parameter, input or output as defined in the
+// app.
+type ParamType string
+
+// The known parameter types.
+const (
+	ParamTypeText                ParamType = "Text"
+	ParamTypeMultiLineText       ParamType = "MultiLineText"
+	ParamTypeInteger             ParamType = "Integer"
+	ParamTypeDouble              ParamType = "Double"
+	ParamTypeFlag                ParamType = "Flag"
+	ParamTypeTextSelection       ParamType = "TextSelection"
+	ParamTypeIntegerSelection    ParamType = "IntegerSelection"
+	ParamTypeDoubleSelection     ParamType = "DoubleSelection"
+	ParamTypeTreeSelection       ParamType = "TreeSelection"
+	ParamTypeEnvironmentVariable ParamType = "EnvironmentVariable"
+	ParamTypeInfo                ParamType = "Info"
+	ParamTypeReferenceGenome     ParamType = "ReferenceGenome"
+	ParamTypeReferenceSequence   ParamType = "ReferenceSequence"
+	ParamTypeReferenceAnnotation ParamType = "ReferenceAnnotation"
+	ParamTypeFileInput           ParamType = "FileInput"
+	ParamTypeFolderInput         ParamType = "FolderInput"
+	ParamTypeMultiFileSelector   ParamType = "MultiFileSelector"
+	ParamTypeFileFolderInput     ParamType = "FileFolderInput"
+	ParamTypeOutput              ParamType = "Output"
+	ParamTypeFileOutput          ParamType = "FileOutput"
+	ParamTypeFolderOutput        ParamType = "FolderOutput"
+	ParamTypeMultiFileOutput     ParamType = "MultiFileOutput"
+	ParamTypeFile                ParamType = "File"
+)
+
+// ParamTypes contains every known ParamType.
+var ParamTypes = []ParamType{
+	ParamTypeText,
+	ParamTypeMultiLineText,
+	ParamTypeInteger,
+	ParamTypeDouble,
+	ParamTypeFlag,
+	ParamTypeTextSelection,
+	ParamTypeIntegerSelection,
+	ParamTypeDoubleSelection,
+	ParamTypeTreeSelection,
+	ParamTypeEnvironmentVariable,
+	ParamTypeInfo,
+	ParamTypeReferenceGenome,
+	ParamTypeReferenceSequence,
+	ParamTypeReferenceAnnotation,
+	ParamTypeFileInput,
+	ParamTypeFolderInput,
+	ParamTypeMultiFileSelector,
+	ParamTypeFileFolderInput,
+	ParamTypeOutput,
+	ParamTypeFileOutput,
+	ParamTypeFolderOutput,
+	ParamTypeMultiFileOutput,
+	ParamTypeFile,
+}
+
+// legacyParamTypes maps older spellings, in lower case, to the current values.
+var legacyParamTypes = map[string]ParamType{
+	"envvar":      ParamTypeEnvironmentVariable,
+	"env":         ParamTypeEnvironmentVariable,
+	"boolean":     ParamTypeFlag,
+	"selection":   ParamTypeTextSelection,
+	"number":      ParamTypeDouble,
+	"input":       ParamTypeFileInput,
+	"multifile":   ParamTypeMultiFileSelector,
+	"folder":      ParamTypeFolderInput,
+	"outputfile":  ParamTypeFileOutput,
+	"multioutput": ParamTypeMultiFileOutput,
+}
+
+// ParseParamType converts a parameter type from a job submission into its
+// canonical spelling. Values are matched without regard to case, and legacy
+// spellings such as "EnvVar" are accepted. Unknown values are returned
+// unchanged.
+func ParseParamType(s string) ParamType {
+	lower := strings.ToLower(strings.TrimSpace(s))
+	for _, t := range ParamTypes {
+		if strings.ToLower(string(t)) == lower {
+			return t
+		}
+	}
+	if t, ok := legacyParamTypes[lower]; ok {
+		return t
+	}
+	return ParamType(s)
+}
+
+// UnmarshalJSON accepts the legacy spellings handled by ParseParamType.
+func (t *ParamType) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalEnum(data, func(s string) string { return string(ParseParamType(s)) })
+	*t = ParamType(s)
+	return err
+}
+
+// Known returns true if the type is one of the known values.
+func (t ParamType) Known() bool {
+	for _, k := range ParamTypes {
+		if t == k {
+			return true
+		}
+	}
+	return false
+}
+
+// IsInput returns true for the types of parameters that refer to input files or
+// folders.
+func (t ParamType) IsInput() bool {
+	switch t {
+	case ParamTypeFileInput, ParamTypeFolderInput, ParamTypeMultiFileSelector, ParamTypeFileFolderInput:
+		return true
+	}
+	return false
+}
+
+// IsOutput returns true for the types of parameters that refer to output files
+// or folders.
+func (t ParamType) IsOutput() bool {
+	switch t {
+	case ParamTypeOutput, ParamTypeFileOutput, ParamTypeFolderOutput, ParamTypeMultiFileOutput:
+		return true
+	}
+	return false
+}
+
+// IsSelection returns true for the types of parameters whose values are chosen
+// from a list.
+func (t ParamType) IsSelection() bool {
+	switch t {
+	case ParamTypeTextSelection, ParamTypeIntegerSelection, ParamTypeDoubleSelection, ParamTypeTreeSelection:
+		return true
+	}
+	return false
+}
+
+// IsReference returns true for the types of parameters that refer to reference
+// genome data.
+func (t ParamType) IsReference() bool {
+	switch t {
+	case ParamTypeReferenceGenome, ParamTypeReferenceSequence, ParamTypeReferenceAnnotation:
+		return true
+	}
+	return false
+}
+
+// StepType describes the execution platform for a step.
+type StepType string
+
+// The known step types.
+const (
+	StepTypeCondor      StepType = "condor"
+	StepTypeInteractive StepType = "interactive"
+	StepTypeOSG         StepType = "osg"
+	StepTypeTapis       StepType = "tapis"
+)
+
+// StepTypes contains every known StepType.
+var StepTypes = []StepType{
+	StepTypeCondor,
+	StepTypeInteractive,
+	StepTypeOSG,
+	StepTypeTapis,
+}
+
+// legacyStepTypes maps older spellings, in lower case, to the current values.
+var legacyStepTypes = map[string]StepType{
+	"agave":    StepTypeTapis,
+	"fapi":     StepTypeTapis,
+	"htcondor": StepTypeCondor,
+	"vice":     StepTypeInteractive,
+}
+
+// ParseStepType converts a step type from a job submission into its canonical
+// spelling. Values are matched without regard to case, and legacy spellings
+// such as "agave" are accepted. Unknown values are returned unchanged.
+func ParseStepType(s string) StepType {
+	lower := strings.ToLower(strings.TrimSpace(s))
+	for _, t := range StepTypes {
+		if string(t) == lower {
+			return t
+		}
+	}
+	if t, ok := legacyStepTypes[lower]; ok {
+		return t
+	}
+	return StepType(s)
+}
+
+// UnmarshalJSON accepts the legacy spellings handled by ParseStepType.
+func (t *StepType) UnmarshalJSON(data []byte) error {
+	s, err := unmarshalEnum(data, func(s string) string { return string(ParseStepType(s)) })
+	*t = StepType(s)
+	return err
+}
+
+// Known returns true if the type is one of the known values.
+func (t StepType) Known() bool {
+	for _, k := range StepTypes {
+		if t == k {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsedMultiplicity returns the input's multiplicity as a Multiplicity.
+func (i *StepInput) ParsedMultiplicity() Multiplicity {
+	return ParseMultiplicity(i.Multiplicity)
+}
+
+// ParsedType returns the input's type as a ParamType.
+func (i *StepInput) ParsedType() ParamType {
+	return ParseParamType(i.Type)
+}
+
+// ParsedMultiplicity returns the output's multiplicity as a Multiplicity.
+func (o *StepOutput) ParsedMultiplicity() Multiplicity {
+	return ParseMultiplicity(o.Multiplicity)
+}
+
+// ParsedType returns the output's type as a ParamType.
+func (o *StepOutput) ParsedType() ParamType {
+	return ParseParamType(o.Type)
+}
+
+// ParsedType returns the parameter's type as a ParamType.
+func (p *StepParam) ParsedType() ParamType {
+	return ParseParamType(p.Type)
+}
+
+// ParsedType returns the step's type as a StepType.
+func (s *Step) ParsedType() StepType {
+	return ParseStepType(s.Type)
+}
@@ -0,0 +1,123 @@
+package model
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestParseMultiplicity(t *testing.T) {
+	values := map[string]Multiplicity{
+		"single":     MultiplicitySingle,
+		"Many":       MultiplicityMany,
+		"collection": MultiplicityCollection,
+		"Folder":     MultiplicityFolder,
+		"One":        MultiplicitySingle,
+		"Files":      MultiplicityMany,
+		"unknown":    Multiplicity("unknown"),
+	}
+	for s, expected := range values {
+		if actual := ParseMultiplicity(s); actual != expected {
+			t.Errorf("ParseMultiplicity(%q) returned '%s' instead of '%s'", s, actual, expected)
+		}
+	}
+	if Multiplicity("unknown").Known() {
+		t.Error("Known() returned true for an unknown multiplicity")
+	}
+	if !MultiplicityFolder.IsCollection() || MultiplicityMany.IsCollection() {
+		t.Error("IsCollection() returned an unexpected value")
+	}
+}
+
+func TestParseParamType(t *testing.T) {
+	values := map[string]ParamType{
+		"FileInput":           ParamTypeFileInput,
+		"fileinput":           ParamTypeFileInput,
+		"EnvironmentVariable": ParamTypeEnvironmentVariable,
+		"EnvVar":              ParamTypeEnvironmentVariable,
+		"boolean":             ParamTypeFlag,
+		"Something":           ParamType("Something"),
+	}
+	for s, expected := range values {
+		if actual := ParseParamType(s); actual != expected {
+			t.Errorf("ParseParamType(%q) returned '%s' instead of '%s'", s, actual, expected)
+		}
+	}
+	if !ParamTypeMultiFileSelector.IsInput() || ParamTypeFlag.IsInput() {
+		t.Error("IsInput() returned an unexpected value")
+	}
+	if !ParamTypeFolderOutput.IsOutput() || ParamTypeFileInput.IsOutput() {
+		t.Error("IsOutput() returned an unexpected value")
+	}
+}
+
+func TestParseStepType(t *testing.T) {
+	values := map[string]StepType{
+		"condor": StepTypeCondor,
+		"Condor": StepTypeCondor,
+		"agave":  StepTypeTapis,
+		"VICE":   StepTypeInteractive,
+		"other":  StepType("other"),
+	}
+	for s, expected := range values {
+		if actual := ParseStepType(s); actual != expected {
+			t.Errorf("ParseStepType(%q) returned '%s' instead of '%s'", s, actual, expected)
+		}
+	}
+}
+
+func TestUnmarshalLegacySpellings(t *testing.T) {
+	var v struct {
+		Multiplicity Multiplicity `json:"multiplicity"`
+		ParamType    ParamType    `json:"param_type"`
+		StepType     StepType     `json:"step_type"`
+		Missing      Multiplicity `json:"missing"`
+	}
+	data := []byte(`{"multiplicity": "One", "param_type": "EnvVar", "step_type": "agave", "missing": null}`)
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	if v.Multiplicity != MultiplicitySingle || v.ParamType != ParamTypeEnvironmentVariable || v.StepType != StepTypeTapis || v.Missing != "" {
+		t.Errorf("unmarshalling returned %#v", v)
+	}
+	if err := json.Unmarshal([]byte(`{"multiplicity": 1}`), &v); err == nil {
+		t.Error("a number was accepted as a multiplicity")
+	}
+}
+
+func TestParsedAccessors(t *testing.T) {
+	var input StepInput
+	data := []byte(`{"multiplicity": "Folder", "type": "folderinput", "value": "/iplant/home/wregglej/dir"}`)
+	if err := json.Unmarshal(data, &input); err != nil {
+		t.Fatal(err)
+	}
+	if input.Multiplicity != "Folder" || input.Type != "folderinput" {
+		t.Errorf("unmarshalling changed the fields to '%s' and '%s'", input.Multiplicity, input.Type)
+	}
+	if input.ParsedMultiplicity() != MultiplicityFolder {
+		t.Errorf("ParsedMultiplicity() returned '%s' instead of '%s'", input.ParsedMultiplicity(), MultiplicityFolder)
+	}
+	if input.ParsedType() != ParamTypeFolderInput {
+		t.Errorf("ParsedType() returned '%s' instead of '%s'", input.ParsedType(), ParamTypeFolderInput)
+	}
+	if actual := input.IRODSPath(); actual != "/iplant/home/wregglej/dir/" {
+		t.Errorf("IRODSPath() returned '%s' instead of '/iplant/home/wregglej/dir/'", actual)
+	}
+
+	legacy := StepInput{Multiplicity: "One"}
+	if legacy.ParsedMultiplicity() != MultiplicitySingle {
+		t.Errorf("ParsedMultiplicity() returned '%s' instead of '%s'", legacy.ParsedMultiplicity(), MultiplicitySingle)
+	}
+	encoded, err := json.Marshal(&legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(encoded), `"multiplicity":"One"`) {
+		t.Errorf("the legacy spelling wasn't kept when encoding: %s", encoded)
+	}
+
+	step := Step{Type: "Condor"}
+	if step.ParsedType() != StepTypeCondor {
+		t.Errorf("ParsedType() returned '%s' instead of '%s'", step.ParsedType(), StepTypeCondor)
+	}
+}
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// The configuration settings read by New and NewFromData.
+const (
+	LogPathConfigKey     = "condor.log_path"
+	FilterFilesConfigKey = "condor.filter_files"
+	IRODSBaseConfigKey   = "irods.base"
+)
+
+// New returns a pointer to a newly instantiated Job with NowDate set.
+// Accesses the following configuration settings:
+//   - condor.log_path
+//   - condor.filter_files
+//   - irods.base
+//
+// New and NewFromData are kept for callers that already have a viper
+// configuration. Use NewJob for the other options; the config package builds
+// them from viper or the environment.
+func New(cfg *viper.Viper) *Job {
+	return newJob(newOptions(ViperOptions(cfg)))
+}
+
+// ViperOptions returns the constructor options for the settings in a viper
+// configuration:
+//   - condor.log_path
+//   - condor.filter_files, a comma-separated list
+//   - irods.base
+//   - condor.backwards_compat_patterns, only if it's set
+func ViperOptions(cfg *viper.Viper) []Option {
+	opts := []Option{
+		WithLogPath(cfg.GetString(LogPathConfigKey)),
+		WithFilterFiles(strings.Split(cfg.GetString(FilterFilesConfigKey), ",")...),
+		WithIRODSBase(cfg.GetString(IRODSBaseConfigKey)),
+	}
+	if cfg.IsSet(BackwardsCompatConfigKey) {
+		opts = append(opts, WithBackwardsCompatPatterns(cfg.GetStringSlice(BackwardsCompatConfigKey)...))
+	}
+	return opts
+}
+
+// NewFromData creates a new submission and populates it by parsing the passed
+// in []byte as JSON. The settings are read from the configuration by
+// ViperOptions(). The options are applied after the ones built from the
+// configuration, so they take precedence.
+func NewFromData(cfg *viper.Viper, data []byte, opts ...Option) (*Job, error) {
+	return NewJob(data, append(ViperOptions(cfg), opts...)...)
+}